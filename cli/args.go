@@ -1,15 +1,42 @@
 package main
 
-import "flag"
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jdetok/bball-etl-cli/etl"
+)
+
 // TODO: add a -logf flag, if it's empty create file
 // - this enables creating file in .sh, passing it and bypassing the InitLogger
 
 type Params struct {
 	Mode [2]string // run mode e.g. build, daily, etc
-	Szn  [2]string // season selector, e.g. 2024 for 2024-25 NBA/2024 WNBA
-	Lg   [2]string // league selector, nba or wnba
+	Szn  [2]string // season selector, 2024, 2024-25 or a season_id like 22024
+	Lg   [2]string // league selector, a League.Code e.g. nba
 	Env  [2]string // prod, dev, test
 	Logf [2]string // log file, if empty create one
+	Rtry [2]string // max attempts per api request
+	Bkof [2]string // base backoff between attempts, e.g. 2s
+	Brk  [2]string // consecutive failed requests before stopping the run
+	RPS  [2]string // api requests per second
+	Bst  [2]string // api request burst
+	CTO  [2]string // api connect timeout
+	RTO  [2]string // api response header (read) timeout
+	TO   [2]string // total timeout per api request
+	Prxy [2]string // proxy url for api requests
+	Base [2]string // api base url override, e.g. http://localhost:8080
+	Fxtr [2]string // read responses from saved json in this dir, no http
+	Rec  [2]string // save every api response to this dir
+	Drft [2]string // schema drift policy: fail, ignore, shared
+	STps [2]string // season types to fetch, e.g. reg,playoffs,playin
+	Date [2]string // daily mode game day (ET), e.g. 2025-01-15
+	From [2]string // backfill mode first game day (ET)
+	To   [2]string // backfill mode last game day (ET), default yesterday
 }
 
 func parseArgs() Params {
@@ -19,14 +46,190 @@ func parseArgs() Params {
 		Lg:   [2]string{"lg", ""},
 		Env:  [2]string{"env", ""},
 		Logf: [2]string{"logf", ""},
+		Rtry: [2]string{"retry", ""},
+		Bkof: [2]string{"backoff", ""},
+		Brk:  [2]string{"brk", ""},
+		RPS:  [2]string{"rps", ""},
+		Bst:  [2]string{"burst", ""},
+		CTO:  [2]string{"conn-timeout", ""},
+		RTO:  [2]string{"read-timeout", ""},
+		TO:   [2]string{"timeout", ""},
+		Prxy: [2]string{"proxy", ""},
+		Base: [2]string{"api-base", ""},
+		Fxtr: [2]string{"fixtures", ""},
+		Rec:  [2]string{"record", ""},
+		Drft: [2]string{"drift", ""},
+		STps: [2]string{"stypes", ""},
+		Date: [2]string{"date", ""},
+		From: [2]string{"from", ""},
+		To:   [2]string{"to", ""},
 	}
 
 	// flag name, default, description
 	flag.StringVar(&p.Mode[1], "mode", "", "etl run-mode")
-	flag.StringVar(&p.Szn[1], "szn", "", "nba/wnba season e.g. 2024")
-	flag.StringVar(&p.Lg[1], "lg", "", "nba or wnba")
+	flag.StringVar(&p.Szn[1], "szn", "", "season e.g. 2024 or 2024-25 (2024-25 nba/2024 wnba)")
+	flag.StringVar(&p.Lg[1], "lg", "", "nba, wnba or gleague, default nba & wnba")
 	flag.StringVar(&p.Env[1], "env", "dev", "prod or dev postgres database")
 	flag.StringVar(&p.Logf[1], "logf", "", "log file, will create if empty")
+	flag.StringVar(&p.Rtry[1], "retry", "5", "max attempts per api request")
+	flag.StringVar(&p.Bkof[1], "backoff", "2s", "base backoff between attempts")
+	flag.StringVar(&p.Brk[1], "brk", "10",
+		"consecutive failed requests before stopping the run, 0 to disable")
+	flag.StringVar(&p.RPS[1], "rps", "1", "api requests per second, 0 for no limit")
+	flag.StringVar(&p.Bst[1], "burst", "2", "max api requests sent back to back")
+	flag.StringVar(&p.CTO[1], "conn-timeout", "10s", "api connect timeout")
+	flag.StringVar(&p.RTO[1], "read-timeout", "60s",
+		"max wait for api response headers")
+	flag.StringVar(&p.TO[1], "timeout", "5m", "total timeout per api request")
+	flag.StringVar(&p.Prxy[1], "proxy", "", "proxy url for api requests")
+	flag.StringVar(&p.Base[1], "api-base", "",
+		"override api scheme & host, e.g. http://localhost:8080")
+	flag.StringVar(&p.Fxtr[1], "fixtures", "",
+		"dir of saved api responses to use instead of http")
+	flag.StringVar(&p.Rec[1], "record", "",
+		"dir to save every api response to, for use with -fixtures")
+	flag.StringVar(&p.Drft[1], "drift", "ignore",
+		"api vs table column mismatch policy: fail, ignore or shared")
+	flag.StringVar(&p.STps[1], "stypes", "",
+		"season types to fetch: all or any of pre,reg,allstar,playoffs,playin,cup"+
+			" (default reg,playoffs,playin,cup)")
+	flag.StringVar(&p.Date[1], "date", "",
+		"daily mode game day (ET) YYYY-MM-DD, default yesterday")
+	flag.StringVar(&p.From[1], "from", "",
+		"backfill mode first game day (ET) YYYY-MM-DD")
+	flag.StringVar(&p.To[1], "to", "",
+		"backfill mode last game day (ET) YYYY-MM-DD, default yesterday")
 	flag.Parse()
 	return p
-}
\ No newline at end of file
+}
+
+// build the retry policy & circuit breaker from the -retry -backoff -brk flags
+func (p *Params) retryConf() (etl.RetryPolicy, *etl.Breaker, error) {
+	rp := etl.DefaultRetry()
+	n, err := strconv.Atoi(p.Rtry[1])
+	if err != nil || n < 1 {
+		return rp, nil, fmt.Errorf("invalid -%s: '%s'", p.Rtry[0], p.Rtry[1])
+	}
+	rp.MaxAttempts = n
+
+	d, err := time.ParseDuration(p.Bkof[1])
+	if err != nil || d < 0 {
+		return rp, nil, fmt.Errorf("invalid -%s: '%s'", p.Bkof[0], p.Bkof[1])
+	}
+	rp.BaseDelay = d
+
+	b, err := strconv.Atoi(p.Brk[1])
+	if err != nil || b < 0 {
+		return rp, nil, fmt.Errorf("invalid -%s: '%s'", p.Brk[0], p.Brk[1])
+	}
+	return rp, etl.NewBreaker(b), nil
+}
+
+// build the shared request limiter from the -rps -burst flags
+func (p *Params) limiter() (*etl.Limiter, error) {
+	rps, err := strconv.ParseFloat(p.RPS[1], 64)
+	if err != nil || rps < 0 {
+		return nil, fmt.Errorf("invalid -%s: '%s'", p.RPS[0], p.RPS[1])
+	}
+	b, err := strconv.Atoi(p.Bst[1])
+	if err != nil || b < 1 {
+		return nil, fmt.Errorf("invalid -%s: '%s'", p.Bst[0], p.Bst[1])
+	}
+	return etl.NewLimiter(rps, b), nil
+}
+
+// build the http client & api base override from the timeout/proxy flags
+func (p *Params) client() (*http.Client, *url.URL, error) {
+	var o etl.ClientOpts
+	for _, d := range []struct {
+		f   [2]string
+		dst *time.Duration
+	}{
+		{p.CTO, &o.ConnTimeout},
+		{p.RTO, &o.ReadTimeout},
+		{p.TO, &o.Timeout},
+	} {
+		v, err := time.ParseDuration(d.f[1])
+		if err != nil || v < 0 {
+			return nil, nil, fmt.Errorf("invalid -%s: '%s'", d.f[0], d.f[1])
+		}
+		*d.dst = v
+	}
+	o.Proxy = p.Prxy[1]
+
+	c, err := etl.NewClient(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Base[1] == "" {
+		return c, nil, nil
+	}
+	base, err := etl.ParseBase(p.Base[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, base, nil
+}
+
+// fetcher the etl gets responses from: saved files with -fixtures, otherwise
+// hf, recording responses to disk with -record
+func (p *Params) fetcher(hf *etl.HTTPFetcher) etl.Fetcher {
+	var f etl.Fetcher = hf
+	if p.Fxtr[1] != "" {
+		f = &etl.FileFetcher{Dir: p.Fxtr[1]}
+	}
+	if p.Rec[1] != "" {
+		f = &etl.RecFetcher{F: f, Dir: p.Rec[1]}
+	}
+	return f
+}
+
+// leagues from -lg, every default league (nba & wnba) if it wasn't passed
+func (p *Params) leagues() ([]etl.League, error) {
+	if p.Lg[1] == "" {
+		return etl.DefaultLeagues(), nil
+	}
+	lg, err := etl.LeagueByCode(p.Lg[1])
+	if err != nil {
+		return nil, err
+	}
+	return []etl.League{lg}, nil
+}
+
+// clock for -date, a daily run as of the morning after that game day
+func (p *Params) clock() (etl.Clock, error) {
+	if p.Date[1] == "" {
+		return etl.SysClock{}, nil
+	}
+	if p.Mode[1] != "daily" {
+		return nil, fmt.Errorf("-%s is only used in daily mode", p.Date[0])
+	}
+	d, err := etl.ParseGameDay(p.Date[1], time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return etl.DateClock(d), nil
+}
+
+// -from & -to game days for backfill mode, -to defaults to yesterday
+func (p *Params) dateRange(cnf *etl.Conf) (time.Time, time.Time, error) {
+	if p.From[1] == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"-%s must be specified in backfill mode", p.From[0])
+	}
+	from, err := etl.ParseGameDay(p.From[1], cnf.Now())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to := cnf.NightlyDay()
+	if p.To[1] != "" {
+		if to, err = etl.ParseGameDay(p.To[1], cnf.Now()); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"-%s %s is before -%s %s", p.To[0], p.To[1], p.From[0], p.From[1])
+	}
+	return from, to, nil
+}
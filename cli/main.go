@@ -10,6 +10,9 @@ PROJECT INTENT:
 	- daily: runs etl for games from previous day
 	- custom (not yet build): pass a season and league (optional) to run the etl
 		for a specific season
+	- draft: draft history for a single year (-szn, default this year)
+	- backfill: game logs for -from through -to (default yesterday) & the
+		current players, after missed daily runs
 
 - TODO:
 	- dev / prod as an argument
@@ -21,8 +24,11 @@ package main
 
 import (
 	// "flag"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/jdetok/bball-etl-cli/etl"
@@ -60,6 +66,17 @@ func main() {
 	// parse flags
 	var p Params = parseArgs()
 
+	// cancel on ctrl-c/SIGTERM, etl stops requesting, running inserts are
+	// finished or rolled back & the summary is still written to the log
+	// a second signal kills the process immediately
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	// init database based on -dev flag
 	var pg pgresd.PostGres
 	switch p.Env[1] {
@@ -81,6 +98,76 @@ func main() {
 	cnf.DB = db
 	cnf.RowCnt = 0
 
+	// http fetcher: retry policy, circuit breaker, rate limit & client
+	hf := &etl.HTTPFetcher{L: &cnf.L} // logger is assigned per mode below
+	hf.Retry, hf.Brk, err = p.retryConf()
+	if err != nil {
+		e.Msg = "error parsing retry flags"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+	hf.Lim, err = p.limiter()
+	if err != nil {
+		e.Msg = "error parsing rate limit flags"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+	hf.Client, hf.Base, err = p.client()
+	if err != nil {
+		e.Msg = "error building http client"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+	cnf.Brk = hf.Brk
+	cnf.F = p.fetcher(hf) // -fixtures/-record wrap or replace the http fetcher
+
+	// what to do when api columns don't match the intake tables
+	cnf.Drift, err = etl.ParseDrift(p.Drft[1])
+	if err != nil {
+		e.Msg = "error parsing drift flag"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+
+	// game day & season resolution, -date runs daily mode for a past day
+	cnf.Clock, err = p.clock()
+	if err != nil {
+		e.Msg = "error parsing date flag"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+	cnf.Pinned = p.Date[1] != ""
+
+	// leagues from -lg, lgID filters game ids to the one passed
+	lgs, err := p.leagues()
+	if err != nil {
+		e.Msg = "error parsing league flag"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+	var lgID string
+	if p.Lg[1] != "" {
+		lgID = lgs[0].ID
+	}
+
+	// -szn as 2024-25, 2024 or 22024, required by the modes that check it
+	var szn etl.Season
+	if p.Szn[1] != "" {
+		if szn, err = etl.ParseSeason(p.Szn[1]); err != nil {
+			e.Msg = "error parsing season flag"
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+	}
+
+	// season types for the game log & shot chart loops
+	cnf.STypes, err = etl.ParseSznTypes(p.STps[1])
+	if err != nil {
+		e.Msg = "error parsing season types flag"
+		fmt.Println(e.BuildErr(err))
+		os.Exit(1)
+	}
+
 	// RUN APPROPRIATE ETL PROCESS BASED ON FLAGS
 	switch p.Mode[1] {
 	case "": // no mode passed,
@@ -113,16 +200,17 @@ func main() {
 		}
 	
 		// RUN NIGHTLY ETL
-		if err = etl.RunNightlyETL(cnf); err != nil {
+		if err = etl.RunNightlyETL(ctx, &cnf); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "daily etl")
 			e.Msg = fmt.Sprintf(
-				"error with %v daily etl", etl.Yesterday(time.Now()))
+				"error with %v daily etl", etl.Yesterday(cnf.Now()))
 			cnf.L.WriteLog(e.Msg)
 			fmt.Println(e.BuildErr(err))
 			os.Exit(1)
 		}
 		compMsg = fmt.Sprintf( // assign in switch
 			"\n---- daily etl for %v complete | total rows affected: %d",
-			etl.Yesterday(time.Now()), cnf.RowCnt,
+			etl.Yesterday(cnf.Now()), cnf.RowCnt,
 		)
 
 		// build etl: all seasons 1970 through current
@@ -137,16 +225,36 @@ func main() {
 
 		// SET START AND END SEASONS
 		var st string = "1970"
-		var en string = time.Now().Format("2006") // current year
+		var en string = cnf.Now().In(etl.ET).Format("2006") // current year
 
 		// RUN ETL
-		if err = etl.RunSeasonETL(cnf, st, en); err != nil {
+		if err = etl.RunSeasonETL(ctx, &cnf, st, en); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "build etl")
 			e.Msg = fmt.Sprintf(
 				"error running season etl: start year: %s | end year: %s", st, en)
 			cnf.L.WriteLog(e.Msg)
 			fmt.Println(e.BuildErr(err))
 			os.Exit(1)
 		}
+
+		// bio details for every player loaded by the season etl
+		if err = etl.PlayerInfosETL(ctx, &cnf, true); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "build etl")
+			e.Msg = "error running player info etl"
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+
+		// every draft in both leagues' history
+		if err = etl.DraftsETL(ctx, &cnf,
+			etl.DefaultLeagues(), ""); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "build etl")
+			e.Msg = "error running draft history etl"
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
 		compMsg = fmt.Sprintf(
 			"\n---- etl for seasons between %s and %s | total rows affected: %d",
 			st, en, cnf.RowCnt,
@@ -173,16 +281,44 @@ func main() {
 			cnf.L = l // assign to cnf
 
 			// RUN FOR BOTH NBA AND WNBA
-			if err := etl.GLogSeasonETL(&cnf, p.Szn[1]); err != nil {
+			if err := etl.GLogSeasonETL(ctx, &cnf, szn.String()); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
 				e.Msg = fmt.Sprintf("error running etl for %s season", p.Szn[1])
 				fmt.Println(e.BuildErr(err))
 				os.Exit(1)
 			}
+			// box score summaries for the season's games
+			if err := etl.GameSumsETL(ctx, &cnf,
+				etl.GameFilter{Szn: szn.Yr()}); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
+				e.Msg = fmt.Sprintf(
+					"error getting box score summaries for %s season", p.Szn[1])
+				fmt.Println(e.BuildErr(err))
+				os.Exit(1)
+			}
+			// advanced box scores for the season's games
+			if err := etl.AdvBoxesETL(ctx, &cnf,
+				etl.GameFilter{Szn: szn.Yr()}); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
+				e.Msg = fmt.Sprintf(
+					"error getting advanced box scores for %s season", p.Szn[1])
+				fmt.Println(e.BuildErr(err))
+				os.Exit(1)
+			}
+			// shot charts for both leagues
+			if err := etl.ShotSeasonETL(ctx, &cnf,
+				lgs, szn.String()); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
+				e.Msg = fmt.Sprintf(
+					"error getting shot charts for %s season", p.Szn[1])
+				fmt.Println(e.BuildErr(err))
+				os.Exit(1)
+			}
 			compMsg = fmt.Sprintf(
 				"\n---- etl for %s nba/wnba seasons | total rows affected: %d",
 				p.Szn[1], cnf.RowCnt,
 			)
-		case "nba", "wnba":
+		default: // a single league, checked by p.leagues()
 			l, err := logd.InitLogger("z_log",
 				fmt.Sprintf("szn_etl_%s_%s", p.Lg[1], p.Szn[1]))
 			if err != nil {
@@ -192,17 +328,138 @@ func main() {
 			}
 			cnf.L = l // assign to cnf
 			// TODO: specific season fetch
-			if err := etl.LgSznGlogs(&cnf, p.Lg[1], p.Szn[1]); err != nil {
+			if err := etl.LgSznGlogs(ctx, &cnf, lgs[0], szn.String()); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
 				e.Msg = fmt.Sprintf("error running etl for %s %s season",
 					p.Szn[1], p.Lg[1])
 				fmt.Println(e.BuildErr(err))
 				os.Exit(1)
 			}
+			if err := etl.GameSumsETL(ctx, &cnf, etl.GameFilter{
+				Lg: lgID, Szn: szn.Yr()}); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
+				e.Msg = fmt.Sprintf(
+					"error getting box score summaries for %s %s season",
+					p.Szn[1], p.Lg[1])
+				fmt.Println(e.BuildErr(err))
+				os.Exit(1)
+			}
+			if err := etl.AdvBoxesETL(ctx, &cnf, etl.GameFilter{
+				Lg: lgID, Szn: szn.Yr()}); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
+				e.Msg = fmt.Sprintf(
+					"error getting advanced box scores for %s %s season",
+					p.Szn[1], p.Lg[1])
+				fmt.Println(e.BuildErr(err))
+				os.Exit(1)
+			}
+			if err := etl.ShotSeasonETL(ctx, &cnf,
+				lgs, szn.String()); err != nil {
+				exitIfCancelled(ctx, &cnf, sTime, "custom etl")
+				e.Msg = fmt.Sprintf(
+					"error getting shot charts for %s %s season",
+					p.Szn[1], p.Lg[1])
+				fmt.Println(e.BuildErr(err))
+				os.Exit(1)
+			}
 			compMsg = fmt.Sprintf(
 				"\n---- etl for %s %s seasons | total rows affected: %d",
 				p.Szn[1], p.Lg[1], cnf.RowCnt,
 			)
 		}
+		// play by play backfill - season required, lg defaults to both
+	case "pbp":
+		if p.Szn[1] == "" {
+			e.Msg = "a season (-szn) must be specified in pbp mode"
+			fmt.Println(e.NewErr())
+			os.Exit(1)
+		}
+		l, err := logd.InitLogger("z_log",
+			fmt.Sprintf("pbp_etl_%s%s", p.Lg[1], p.Szn[1]))
+		if err != nil {
+			e.Msg = "error initializing logger"
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		cnf.L = l // assign to cnf
+
+		// games already in intake.gm_team with no play by play yet
+		if err := etl.PBPsETL(ctx, &cnf, etl.GameFilter{
+			Lg: lgID, Szn: szn.Yr()}); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "pbp etl")
+			e.Msg = fmt.Sprintf("error running play by play etl for %s %s",
+				p.Lg[1], p.Szn[1])
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		compMsg = fmt.Sprintf(
+			"\n---- play by play etl for %s %s season | total rows affected: %d",
+			p.Lg[1], p.Szn[1], cnf.RowCnt,
+		)
+
+		// game log backfill for missed daily runs - -from required, -to
+		// defaults to yesterday, -lg defaults to both
+	case "backfill":
+		from, to, err := p.dateRange(&cnf)
+		if err != nil {
+			e.Msg = "error parsing backfill dates"
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		dates := fmt.Sprintf("%s - %s",
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+		l, err := logd.InitLogger("z_log", fmt.Sprintf("bkfl_etl_%s%s_%s",
+			p.Lg[1], from.Format("20060102"), to.Format("20060102")))
+		if err != nil {
+			e.Msg = "error initializing logger"
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		cnf.L = l // assign to cnf
+
+		if err := etl.RunBackfillETL(ctx, &cnf, lgs, from, to); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "backfill etl")
+			e.Msg = fmt.Sprintf("error running backfill etl for %s %s",
+				p.Lg[1], dates)
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		compMsg = fmt.Sprintf(
+			"\n---- backfill etl for %s %s | total rows affected: %d",
+			p.Lg[1], dates, cnf.RowCnt,
+		)
+
+		// draft refresh - run each summer after the drafts, -szn defaults to
+		// this year, -lg defaults to both
+	case "draft":
+		var dYr string = cnf.Now().In(etl.ET).Format("2006")
+		if p.Szn[1] != "" {
+			dYr = szn.Yr()
+		}
+		l, err := logd.InitLogger("z_log",
+			fmt.Sprintf("draft_etl_%s%s", p.Lg[1], dYr))
+		if err != nil {
+			e.Msg = "error initializing logger"
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		cnf.L = l // assign to cnf
+
+		if err := etl.DraftsETL(ctx, &cnf, lgs, dYr); err != nil {
+			exitIfCancelled(ctx, &cnf, sTime, "draft etl")
+			e.Msg = fmt.Sprintf("error running draft etl for %s %s",
+				p.Lg[1], dYr)
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+			os.Exit(1)
+		}
+		compMsg = fmt.Sprintf(
+			"\n---- draft etl for %s %s | total rows affected: %d",
+			p.Lg[1], dYr, cnf.RowCnt,
+		)
+
 		// EMAIL MODE: RUN AT END OF SH
 	case "email":
 		// email log file to myself
@@ -230,6 +487,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	logSummary(&cnf, sTime, compMsg)
+
+	// // email log file to myself
+	// EmailLog(cnf.L)
+	// if err != nil {
+	// 	e.Msg = "error emailing log"
+	// 	cnf.L.WriteLog(e.Msg)
+	// 	fmt.Println(e.BuildErr(err))
+	// 	os.Exit(1)
+	// }
+
+	// cnf.L.WriteLog("email sent - exiting bball-etl-cli")
+}
+
+// write errors & start/end/duration to the log
+func logSummary(cnf *etl.Conf, sTime time.Time, compMsg string) {
 	// write errors to the log
 	if len(cnf.Errs) > 0 {
 		cnf.L.WriteLog(fmt.Sprintln("ERRORS:"))
@@ -238,6 +511,22 @@ func main() {
 		}
 	}
 
+	// write warnings (schema drift etc) to the log
+	if len(cnf.Warns) > 0 {
+		cnf.L.WriteLog(fmt.Sprintln("WARNINGS:"))
+		for _, w := range cnf.Warns {
+			cnf.L.WriteLog(fmt.Sprintln(w))
+		}
+	}
+
+	// write roster transactions detected this run to the log
+	if len(cnf.Events) > 0 {
+		cnf.L.WriteLog(fmt.Sprintln("TRANSACTIONS:"))
+		for _, ev := range cnf.Events {
+			cnf.L.WriteLog(fmt.Sprintln(ev))
+		}
+	}
+
 	// complete log
 	cnf.L.WriteLog(
 		fmt.Sprint(
@@ -251,15 +540,17 @@ func main() {
 			compMsg, // assigned in switch based on passed mode
 		),
 	)
+}
 
-	// // email log file to myself
-	// EmailLog(cnf.L)
-	// if err != nil {
-	// 	e.Msg = "error emailing log"
-	// 	cnf.L.WriteLog(e.Msg)
-	// 	fmt.Println(e.BuildErr(err))
-	// 	os.Exit(1)
-	// }
-
-	// cnf.L.WriteLog("email sent - exiting bball-etl-cli")
+// run was interrupted by a signal: log the summary of what got done & exit
+func exitIfCancelled(
+	ctx context.Context, cnf *etl.Conf, sTime time.Time, run string) {
+	if ctx.Err() == nil {
+		return
+	}
+	logSummary(cnf, sTime, fmt.Sprintf(
+		"\n---- %s interrupted by signal | total rows affected: %d",
+		run, cnf.RowCnt))
+	fmt.Printf("%s interrupted, summary written to log\n", run)
+	os.Exit(130)
 }
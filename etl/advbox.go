@@ -0,0 +1,135 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+advanced box score endpoints, one call per game each
+  - boxscoreadvancedv2: off/def/net rating, pace, usage, PIE
+  - boxscorefourfactorsv2: efg%, fta rate, tov%, oreb% & opponent's
+  - boxscoreusagev2: share of the team's stats while on the floor
+
+each loads a player & a team table, the team set is loaded last & marks the
+game as done for NewGameIDs
+*/
+type AdvBox struct {
+	What     string // for the log, e.g. "advanced box score"
+	Endpoint string
+	Sets     []SetTbl
+}
+
+var ADV_BOXES = []AdvBox{
+	{
+		What:     "advanced box score",
+		Endpoint: "/stats/boxscoreadvancedv2",
+		Sets: []SetTbl{
+			{
+				Set:     "PlayerStats",
+				Tbl:     "intake.box_adv_plr",
+				PrimKey: "game_id, player_id",
+			},
+			{
+				Set:     "TeamStats",
+				Tbl:     "intake.box_adv_tm",
+				PrimKey: "game_id, team_id",
+			},
+		},
+	},
+	{
+		What:     "four factors box score",
+		Endpoint: "/stats/boxscorefourfactorsv2",
+		Sets: []SetTbl{
+			{
+				Set:     "sqlPlayersFourFactors",
+				Tbl:     "intake.box_ff_plr",
+				PrimKey: "game_id, player_id",
+			},
+			{
+				Set:     "sqlTeamsFourFactors",
+				Tbl:     "intake.box_ff_tm",
+				PrimKey: "game_id, team_id",
+			},
+		},
+	},
+	{
+		What:     "usage box score",
+		Endpoint: "/stats/boxscoreusagev2",
+		Sets: []SetTbl{
+			{
+				Set:     "sqlPlayersUsage",
+				Tbl:     "intake.box_usg_plr",
+				PrimKey: "game_id, player_id",
+			},
+			{
+				Set:     "sqlTeamsUsage",
+				Tbl:     "intake.box_usg_tm",
+				PrimKey: "game_id, team_id",
+			},
+		},
+	},
+}
+
+// full game: periods 0-10 & the whole range in tenths of a second
+func AdvBoxReq(endpoint, gameID string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: endpoint,
+	}
+	gr.SetParams([]Pair{
+		{"GameID", gameID},
+		{"StartPeriod", "0"},
+		{"EndPeriod", "10"},
+		{"StartRange", "0"},
+		{"EndRange", "28800"},
+		{"RangeType", "0"},
+	})
+	return gr
+}
+
+// table NewGameIDs checks for games already loaded, the last set's
+func (b AdvBox) doneTbl() string {
+	return b.Sets[len(b.Sets)-1].Tbl
+}
+
+// fetch one advanced box score for a game & load its player & team sets
+func AdvBoxETL(ctx context.Context, cnf *Conf, b AdvBox, gameID string) error {
+	e := errd.InitErr()
+	r := AdvBoxReq(b.Endpoint, gameID)
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: game %s",
+			r.Endpoint, gameID)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp, b.Sets)
+}
+
+/*
+every advanced box score for games in intake.gm_team matching f that aren't
+loaded yet, run after the game logs are loaded
+*/
+func AdvBoxesETL(ctx context.Context, cnf *Conf, f GameFilter) error {
+	e := errd.InitErr()
+	for _, b := range ADV_BOXES {
+		gids, err := NewGameIDs(ctx, cnf, b.doneTbl(), f)
+		if err != nil {
+			e.Msg = fmt.Sprintf("error getting new game ids for %s %+v",
+				b.What, f)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		if err := PerGameETL(ctx, cnf, b.What, gids,
+			func(gid string) error {
+				return AdvBoxETL(ctx, cnf, b, gid)
+			}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package etl
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/*
+options for the http client used for api requests, zero means no limit
+except the tls handshake, which keeps the default transport's 10s without a
+ConnTimeout
+*/
+type ClientOpts struct {
+	ConnTimeout time.Duration // dial & tls handshake
+	ReadTimeout time.Duration // wait for response headers after sending
+	Timeout     time.Duration // entire request, including reading the body
+	Proxy       string        // proxy url, empty uses HTTP(S)_PROXY env vars
+}
+
+// build an http client from opts, assign to HTTPFetcher.Client
+func NewClient(o ClientOpts) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if o.Proxy != "" {
+		pu, err := url.Parse(o.Proxy)
+		if err != nil || pu.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url '%s'", o.Proxy)
+		}
+		proxy = http.ProxyURL(pu)
+	}
+
+	dl := &net.Dialer{
+		Timeout:   o.ConnTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = proxy
+	tr.DialContext = dl.DialContext
+	if o.ConnTimeout > 0 {
+		tr.TLSHandshakeTimeout = o.ConnTimeout
+	}
+	tr.ResponseHeaderTimeout = o.ReadTimeout
+
+	return &http.Client{
+		Transport: tr,
+		Timeout:   o.Timeout,
+	}, nil
+}
+
+/*
+parse an api base url override like http://localhost:8080
+scheme & host replace https://stats.nba.com on every request
+*/
+func ParseBase(base string) (*url.URL, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api base url '%s': %w", base, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf(
+			"invalid api base url '%s': must be like http(s)://host[:port]", base)
+	}
+	return u, nil
+}
+
+// hf.Client if set, otherwise http.DefaultClient
+func (hf *HTTPFetcher) client() *http.Client {
+	if hf.Client != nil {
+		return hf.Client
+	}
+	return http.DefaultClient
+}
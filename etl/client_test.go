@@ -0,0 +1,39 @@
+package etl
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewClientTimeouts(t *testing.T) {
+	dflt := http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout
+	tests := []struct {
+		name string
+		o    ClientOpts
+		tls  time.Duration
+	}{
+		{"no conn timeout keeps the tls default", ClientOpts{}, dflt},
+		{"conn timeout limits the handshake",
+			ClientOpts{ConnTimeout: 3 * time.Second}, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := c.Transport.(*http.Transport)
+			if tr.TLSHandshakeTimeout != tt.tls {
+				t.Errorf("TLSHandshakeTimeout = %v, want %v",
+					tr.TLSHandshakeTimeout, tt.tls)
+			}
+			if tr.ResponseHeaderTimeout != tt.o.ReadTimeout ||
+				c.Timeout != tt.o.Timeout {
+				t.Errorf("read/total timeouts = %v/%v, want %v/%v",
+					tr.ResponseHeaderTimeout, c.Timeout,
+					tt.o.ReadTimeout, tt.o.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package etl
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // America/New_York without the host's zoneinfo
+)
+
+/*
+league calendar: game days & seasons are resolved in US/Eastern (the league's
+schedule time zone) from cnf.Clock, not the host's local time
+a -date run swaps the clock for a fixed time the morning after that day
+*/
+
+var ET = mustLoc("America/New_York")
+
+func mustLoc(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// current time for date resolution
+type Clock interface {
+	Now() time.Time
+}
+
+// the system clock
+type SysClock struct{}
+
+func (SysClock) Now() time.Time { return time.Now() }
+
+// a clock stopped at T
+type FixedClock struct{ T time.Time }
+
+func (c FixedClock) Now() time.Time { return c.T }
+
+// clock for a run as of the morning (6am ET) after game day d
+func DateClock(d time.Time) FixedClock {
+	return FixedClock{GameDay(d).AddDate(0, 0, 1).Add(6 * time.Hour)}
+}
+
+// now from cnf.Clock, or the system clock if it wasn't set
+func (cnf *Conf) Now() time.Time {
+	if cnf.Clock == nil {
+		return time.Now()
+	}
+	return cnf.Clock.Now()
+}
+
+// midnight ET of t's game day
+func GameDay(t time.Time) time.Time {
+	y, m, d := t.In(ET).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, ET)
+}
+
+// game day the nightly run loads, the day before cnf.Now() in ET
+func (cnf *Conf) NightlyDay() time.Time {
+	return GameDay(cnf.Now()).AddDate(0, 0, -1)
+}
+
+/*
+-date value (YYYY-MM-DD) as a game day, must be before today in ET
+the nightly run can't load a day that isn't over yet
+*/
+func ParseGameDay(s string, now time.Time) (time.Time, error) {
+	d, err := time.ParseInLocation("2006-01-02", s, ET)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"invalid date '%s': must be YYYY-MM-DD", s)
+	}
+	if !d.Before(GameDay(now)) {
+		return time.Time{}, fmt.Errorf(
+			"invalid date '%s': must be before today (%s ET)",
+			s, GameDay(now).Format("2006-01-02"))
+	}
+	return d, nil
+}
+
+/*
+season a game day belongs to: the current year's from the league's first
+month, the previous year's before it (nba jan-sep is the previous year's)
+*/
+func (lg League) SznOn(t time.Time) Season {
+	y, m, _ := t.In(ET).Date()
+	if m >= lg.StartMo {
+		return Season(y)
+	}
+	return Season(y - 1)
+}
+
+// whether t falls between the league's first & last month (playoffs included)
+func (lg League) Active(t time.Time) bool {
+	m := t.In(ET).Month()
+	if lg.StartMo <= lg.EndMo { // within a calendar year, e.g. wnba may-oct
+		return m >= lg.StartMo && m <= lg.EndMo
+	}
+	return m >= lg.StartMo || m <= lg.EndMo
+}
+
+// season string for the nightly run's game day in the league's format
+func (cnf *Conf) CrntSzn(lg League) string {
+	return lg.Szn(lg.SznOn(cnf.NightlyDay()))
+}
+
+// e.g. "NBA 2024-25, WNBA 2025 (offseason)" for the nightly log
+func ActiveSzns(lgs []League, t time.Time) string {
+	var s string
+	for i, lg := range lgs {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s %s", lg.Abbr, lg.Szn(lg.SznOn(t)))
+		if !lg.Active(t) {
+			s += " (offseason)"
+		}
+	}
+	return s
+}
+
+// part of a date range within one of a league's seasons, game days in ET
+type SznSpan struct {
+	Szn      Season
+	From, To time.Time
+}
+
+// first day of a league's season, the 1st of StartMo
+func (lg League) SznStart(s Season) time.Time {
+	return time.Date(int(s), lg.StartMo, 1, 0, 0, 0, 0, ET)
+}
+
+/*
+split from - to (game days, inclusive) by the league's seasons, e.g. nba
+2025-09-20 - 2025-10-25 is 2024-25 through 09/30 & 2025-26 from 10/01
+parts before the league's first season or entirely in the offseason are left out
+*/
+func (lg League) SznSpans(from, to time.Time) []SznSpan {
+	from, to = GameDay(from), GameDay(to)
+	if to.Before(from) {
+		return nil
+	}
+	var sps []SznSpan
+	for _, s := range SeasonRange(lg.SznOn(from), lg.SznOn(to)) {
+		if s < lg.First {
+			continue
+		}
+		sp := SznSpan{Szn: s, From: from, To: to}
+		if st := lg.SznStart(s); st.After(sp.From) {
+			sp.From = st
+		}
+		if end := lg.SznStart(s+1).AddDate(0, 0, -1); end.Before(sp.To) {
+			sp.To = end
+		}
+		if lg.activeIn(sp.From, sp.To) {
+			sps = append(sps, sp)
+		}
+	}
+	return sps
+}
+
+// whether any month from - to is one of the league's active months
+func (lg League) activeIn(from, to time.Time) bool {
+	m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, ET)
+	for ; !m.After(to); m = m.AddDate(0, 1, 0) {
+		if lg.Active(m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package etl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+decode the rows of a result set into structs by header name instead of column
+position, fields are matched with a `col` tag, case insensitive:
+
+	type X struct {
+		GameID string  `col:"GAME_ID"`
+		Pts    int     `col:"PTS"`
+		Plr    *int64  `col:"PLAYER_ID,opt"` // opt: header may be absent
+	}
+
+supported field types: string, json.Number, bool, ints, floats, any & pointers
+to those (nil for null values)
+resp bodies are decoded with json.Number, so big ids keep their precision
+
+returns a *ColErr when tagged columns are missing from the headers or headers
+have no field: rows are nil when columns are missing, but still decoded when
+there are only extra headers (see OnlyExtra)
+*/
+func DecodeRows[T any](rs ResultSet) ([]T, error) {
+	var zero T
+	typ := reflect.TypeOf(zero)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("DecodeRows: %T is not a struct", zero)
+	}
+
+	// map header position to field index
+	hdrIdx := make(map[string]int, len(rs.Headers))
+	for i, h := range rs.Headers {
+		hdrIdx[strings.ToUpper(h)] = i
+	}
+	fldCol := make(map[int]int) // field index: column index
+	used := make(map[int]bool)
+	ce := &ColErr{Set: rs.Name}
+	for i := range typ.NumField() {
+		name, opt, ok := colTag(typ.Field(i))
+		if !ok {
+			continue
+		}
+		c, ok := hdrIdx[name]
+		if !ok {
+			if !opt {
+				ce.Missing = append(ce.Missing, name)
+			}
+			continue
+		}
+		fldCol[i] = c
+		used[c] = true
+	}
+	for i, h := range rs.Headers {
+		if !used[i] {
+			ce.Extra = append(ce.Extra, h)
+		}
+	}
+	if len(ce.Missing) > 0 {
+		return nil, ce
+	}
+
+	out := make([]T, 0, len(rs.RowSet))
+	for r, row := range rs.RowSet {
+		var t T
+		v := reflect.ValueOf(&t).Elem()
+		for f, c := range fldCol {
+			if c >= len(row) {
+				return nil, fmt.Errorf("%s row %d: only %d values, no %s",
+					rs.Name, r, len(row), rs.Headers[c])
+			}
+			if err := setField(v.Field(f), row[c]); err != nil {
+				return nil, fmt.Errorf("%s row %d, %s: %w",
+					rs.Name, r, rs.Headers[c], err)
+			}
+		}
+		out = append(out, t)
+	}
+
+	if len(ce.Extra) > 0 {
+		return out, ce
+	}
+	return out, nil
+}
+
+// headers that don't line up with the struct passed to DecodeRows
+type ColErr struct {
+	Set     string
+	Missing []string // tagged fields with no header
+	Extra   []string // headers with no field
+}
+
+func (ce *ColErr) Error() string {
+	return fmt.Sprintf("result set %s: missing columns %v | extra columns %v",
+		ce.Set, ce.Missing, ce.Extra)
+}
+
+// true when err is a *ColErr with extra headers only, rows are still usable
+func OnlyExtra(err error) bool {
+	var ce *ColErr
+	return errors.As(err, &ce) && len(ce.Missing) == 0
+}
+
+/*
+result set from typed rows, the reverse of DecodeRows, e.g. to insert rows a
+flow decoded: cols are the headers to write in order, each needs a tagged
+field in T, nil pointers are written as null
+*/
+func EncodeRows[T any](name string, cols []string, ts []T) (ResultSet, error) {
+	var zero T
+	typ := reflect.TypeOf(zero)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return ResultSet{}, fmt.Errorf("EncodeRows: %T is not a struct", zero)
+	}
+	tagFld := make(map[string]int)
+	for i := range typ.NumField() {
+		if col, _, ok := colTag(typ.Field(i)); ok {
+			tagFld[col] = i
+		}
+	}
+	flds := make([]int, len(cols))
+	ce := &ColErr{Set: name}
+	for i, c := range cols {
+		f, ok := tagFld[strings.ToUpper(c)]
+		if !ok {
+			ce.Extra = append(ce.Extra, c)
+		}
+		flds[i] = f
+	}
+	if len(ce.Extra) > 0 {
+		return ResultSet{}, ce
+	}
+
+	rs := ResultSet{Name: name, Headers: cols}
+	for _, t := range ts {
+		v := reflect.ValueOf(t)
+		row := make([]any, len(cols))
+		for i, f := range flds {
+			fv := v.Field(f)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			row[i] = fv.Interface()
+		}
+		rs.RowSet = append(rs.RowSet, row)
+	}
+	return rs, nil
+}
+
+// headers that have a tagged field in T, in header order
+func FieldCols[T any](hdrs []string) []string {
+	var zero T
+	typ := reflect.TypeOf(zero)
+	tags := make(map[string]bool)
+	for i := range typ.NumField() {
+		if col, _, ok := colTag(typ.Field(i)); ok {
+			tags[col] = true
+		}
+	}
+	var cols []string
+	for _, h := range hdrs {
+		if tags[strings.ToUpper(h)] {
+			cols = append(cols, h)
+		}
+	}
+	return cols
+}
+
+// column name & opt flag from a `col:"NAME[,opt]"` tag
+func colTag(f reflect.StructField) (string, bool, bool) {
+	tag, ok := f.Tag.Lookup("col")
+	if !ok || tag == "" || tag == "-" || !f.IsExported() {
+		return "", false, false
+	}
+	name, opt, _ := strings.Cut(tag, ",")
+	return strings.ToUpper(name), opt == "opt", true
+}
+
+// set a struct field from a value in a decoded rowSet
+func setField(fv reflect.Value, val any) error {
+	if val == nil {
+		fv.SetZero()
+		return nil
+	}
+	if fv.Kind() == reflect.Pointer {
+		p := reflect.New(fv.Type().Elem())
+		if err := setField(p.Elem(), val); err != nil {
+			return err
+		}
+		fv.Set(p)
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.Interface:
+		fv.Set(reflect.ValueOf(val))
+	case reflect.String:
+		switch x := val.(type) {
+		case string:
+			fv.SetString(x)
+		case json.Number:
+			fv.SetString(x.String())
+		case bool:
+			fv.SetString(strconv.FormatBool(x))
+		case float64:
+			fv.SetString(strconv.FormatFloat(x, 'f', -1, 64))
+		default:
+			return fmt.Errorf("can't decode %T into string", val)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := numStr(val)
+		if err != nil {
+			return err
+		}
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			// e.g. 1.0 sent for an int column
+			f, ferr := strconv.ParseFloat(n, 64)
+			if ferr != nil || f != float64(int64(f)) {
+				return fmt.Errorf("can't decode %v into int: %w", val, err)
+			}
+			i = int64(f)
+		}
+		if fv.OverflowInt(i) {
+			return fmt.Errorf("%d overflows %s", i, fv.Type())
+		}
+		fv.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		n, err := numStr(val)
+		if err != nil {
+			return err
+		}
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return fmt.Errorf("can't decode %v into float: %w", val, err)
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		switch x := val.(type) {
+		case bool:
+			fv.SetBool(x)
+		default:
+			n, err := numStr(val)
+			if err != nil {
+				return err
+			}
+			b, err := strconv.ParseBool(n)
+			if err != nil {
+				return fmt.Errorf("can't decode %v into bool: %w", val, err)
+			}
+			fv.SetBool(b)
+		}
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
+
+// string form of a number (or numeric string) from a rowSet
+func numStr(val any) (string, error) {
+	switch x := val.(type) {
+	case json.Number:
+		return x.String(), nil
+	case string:
+		return strings.TrimSpace(x), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case int, int8, int16, int32, int64: // rows built by EncodeRows
+		return fmt.Sprint(x), nil
+	}
+	return "", fmt.Errorf("can't decode %T as a number", val)
+}
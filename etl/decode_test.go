@@ -0,0 +1,179 @@
+package etl
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type decRow struct {
+	ID    int64    `col:"ID"`
+	Name  string   `col:"NAME"`
+	Pts   *int     `col:"PTS"`
+	Pct   *float64 `col:"PCT"`
+	Flag  bool     `col:"FLAG,opt"`
+	Raw   any      `col:"RAW,opt"`
+	Skip  string   // no tag, never decoded
+	Never string   `col:"-"`
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestDecodeRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		rs      ResultSet
+		want    []decRow
+		missing []string
+		extra   []string
+	}{
+		{"by header name, any order & case",
+			ResultSet{Name: "X", Headers: []string{"pts", "Name", "ID", "PCT"},
+				RowSet: [][]any{{json.Number("31"), "Ava", json.Number("7"),
+					json.Number("0.5")}}},
+			[]decRow{{ID: 7, Name: "Ava", Pts: ptr(31), Pct: ptr(0.5)}},
+			nil, nil},
+		{"nulls are zero values & nil pointers",
+			ResultSet{Name: "X", Headers: []string{"ID", "NAME", "PTS", "PCT"},
+				RowSet: [][]any{{json.Number("7"), nil, nil, nil}}},
+			[]decRow{{ID: 7}}, nil, nil},
+		{"numeric strings, 1.0 ints & 0/1 bools",
+			ResultSet{Name: "X",
+				Headers: []string{"ID", "NAME", "PTS", "PCT", "FLAG"},
+				RowSet: [][]any{{"7", json.Number("12"), json.Number("3.0"),
+					"0.25", json.Number("1")}}},
+			[]decRow{{ID: 7, Name: "12", Pts: ptr(3), Pct: ptr(0.25), Flag: true}},
+			nil, nil},
+		{"extra headers still decode",
+			ResultSet{Name: "X",
+				Headers: []string{"ID", "NAME", "PTS", "PCT", "NEW_COL"},
+				RowSet:  [][]any{{json.Number("7"), "Ava", nil, nil, "x"}}},
+			[]decRow{{ID: 7, Name: "Ava"}}, nil, []string{"NEW_COL"}},
+		{"missing headers return no rows",
+			ResultSet{Name: "X", Headers: []string{"ID", "NAME"},
+				RowSet: [][]any{{json.Number("7"), "Ava"}}},
+			nil, []string{"PTS", "PCT"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRows[decRow](tt.rs)
+			var ce *ColErr
+			switch {
+			case tt.missing == nil && tt.extra == nil && err != nil:
+				t.Fatalf("err = %v, want nil", err)
+			case (tt.missing != nil || tt.extra != nil) && !errors.As(err, &ce):
+				t.Fatalf("err = %v, want *ColErr", err)
+			}
+			if ce != nil {
+				if !slices.Equal(ce.Missing, tt.missing) ||
+					!slices.Equal(ce.Extra, tt.extra) {
+					t.Errorf("missing %v extra %v, want %v %v",
+						ce.Missing, ce.Extra, tt.missing, tt.extra)
+				}
+				if OnlyExtra(err) != (tt.missing == nil) {
+					t.Errorf("OnlyExtra = %v with missing %v",
+						OnlyExtra(err), tt.missing)
+				}
+			}
+			if !slices.EqualFunc(got, tt.want, decRowEq) {
+				t.Errorf("rows = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func decRowEq(a, b decRow) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.Flag == b.Flag &&
+		(a.Pts == nil) == (b.Pts == nil) && (a.Pts == nil || *a.Pts == *b.Pts) &&
+		(a.Pct == nil) == (b.Pct == nil) && (a.Pct == nil || *a.Pct == *b.Pct)
+}
+
+func TestDecodeRowsErrs(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []any
+	}{
+		{"short row", []any{json.Number("7"), "Ava"}},
+		{"not a number", []any{"seven", "Ava", nil, nil}},
+		{"fraction into int",
+			[]any{json.Number("7"), "Ava", json.Number("2.5"), nil}},
+		{"bool into int", []any{true, "Ava", nil, nil}},
+	}
+	for _, tt := range tests {
+		rs := ResultSet{Name: "X", Headers: []string{"ID", "NAME", "PTS", "PCT"},
+			RowSet: [][]any{tt.row}}
+		if _, err := DecodeRows[decRow](rs); err == nil {
+			t.Errorf("%s: decoded %v without an error", tt.name, tt.row)
+		}
+	}
+	if _, err := DecodeRows[int](ResultSet{}); err == nil {
+		t.Error("decoded into an int")
+	}
+}
+
+// ids past 2^53 survive the trip from response body to struct & back
+func TestDecodeRowsPrecision(t *testing.T) {
+	body := []byte(`{"resultSets":[{"name":"X","headers":["ID","NAME","PTS","PCT","RAW"],
+		"rowSet":[[9007199254740993,"Ava",null,0.1,1610612747]]}]}`)
+	resp, err := UnmarshalInto(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := DecodeRows[decRow](resp.ResultSets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].ID != 9007199254740993 { // float64 would give ...992
+		t.Errorf("ID = %d, want 9007199254740993", rows[0].ID)
+	}
+	if rows[0].Raw != json.Number("1610612747") {
+		t.Errorf("any field = %#v, want json.Number", rows[0].Raw)
+	}
+
+	rs, err := EncodeRows("X", resp.ResultSets[0].Headers, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{int64(9007199254740993), "Ava", nil, 0.1,
+		json.Number("1610612747")}
+	if !slices.Equal(rs.RowSet[0], want) {
+		t.Errorf("encoded %#v, want %#v", rs.RowSet[0], want)
+	}
+}
+
+func TestEncodeRows(t *testing.T) {
+	rows := []decRow{
+		{ID: 1, Name: "Ava", Pts: ptr(30)},
+		{ID: 2, Name: "Ben", Pct: ptr(0.5), Flag: true},
+	}
+	rs, err := EncodeRows("X", []string{"NAME", "id", "PTS", "PCT"}, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]any{{"Ava", int64(1), 30, nil}, {"Ben", int64(2), nil, 0.5}}
+	for i := range want {
+		if !slices.Equal(rs.RowSet[i], want[i]) {
+			t.Errorf("row %d = %#v, want %#v", i, rs.RowSet[i], want[i])
+		}
+	}
+
+	// round trip
+	back, err := DecodeRows[decRow](rs)
+	if err != nil && !OnlyExtra(err) {
+		t.Fatal(err)
+	}
+	if back[0].ID != 1 || *back[0].Pts != 30 || back[1].Pct == nil {
+		t.Errorf("decoded back %+v", back)
+	}
+
+	var ce *ColErr
+	_, err = EncodeRows("X", []string{"ID", "SKIP"}, rows)
+	if !errors.As(err, &ce) || !slices.Equal(ce.Extra, []string{"SKIP"}) {
+		t.Errorf("encoding an untagged column err = %v, want *ColErr", err)
+	}
+	got := FieldCols[decRow]([]string{"new", "pts", "ID", "Skip"})
+	if !slices.Equal(got, []string{"pts", "ID"}) {
+		t.Errorf("FieldCols = %v, want [pts ID]", got)
+	}
+}
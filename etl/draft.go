@@ -0,0 +1,74 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+calls drafthistory for every pick in a league's history, or a single draft
+year for the summer refresh, upserted into intake.draft
+lg.v_draft links picks to lg.plr & lg.team with left joins, players who never
+played (no lg.plr row) still show up
+*/
+
+// picks are updated, a refresh can change a pick's team or organization
+var DRAFT_TBL = SetTbl{
+	Set:     "DraftHistory",
+	Tbl:     "intake.draft",
+	PrimKey: "person_id, season",
+	Upsert:  true,
+}
+
+// empty season returns every draft in the league's history
+func DraftReq(league, season string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/drafthistory",
+	}
+	gr.SetParams([]Pair{
+		{"LeagueID", league},
+		{"Season", season},
+	})
+	return gr
+}
+
+// fetch & upsert a league's draft history, season as YYYY or empty for all
+func DraftETL(ctx context.Context, cnf *Conf, league, season string) error {
+	e := errd.InitErr()
+	r := DraftReq(league, season)
+	cnf.L.WriteLog(fmt.Sprintf("attempting to fetch %s: LG=%s, SZN=%s",
+		r.Endpoint, league, season))
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: LG=%s, SZN=%s",
+			r.Endpoint, league, season)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp, []SetTbl{DRAFT_TBL})
+}
+
+/*
+draft history for every league in lgs, season as YYYY for a single draft
+build mode: every draft, draft mode: this year's draft each summer
+*/
+func DraftsETL(
+	ctx context.Context, cnf *Conf, lgs []League, season string,
+) error {
+	e := errd.InitErr()
+	for _, lg := range lgs {
+		if err := DraftETL(ctx, cnf, lg.ID, season); err != nil {
+			e.Msg = fmt.Sprintf("error during draft ETL. LG=%s, SZN=%s",
+				lg.Code, season)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		cnf.L.WriteLog(fmt.Sprintf("finished with draft LG=%s, SZN=%s",
+			lg.Code, season))
+	}
+	return nil
+}
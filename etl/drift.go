@@ -0,0 +1,185 @@
+package etl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+what to do when a response's headers don't match the target table's columns
+  - fail: new or missing columns fail the insert
+  - ignore (default): new api columns are dropped from the insert, missing
+    ones fail, a column the api adds doesn't stop a nightly load
+  - shared: insert only the columns in both the response & the table
+
+order changes are only logged, inserts always name their columns
+*/
+type DriftPolicy string
+
+const (
+	DRIFT_FAIL   DriftPolicy = "fail"
+	DRIFT_IGNORE DriftPolicy = "ignore"
+	DRIFT_SHARED DriftPolicy = "shared"
+)
+
+func ParseDrift(s string) (DriftPolicy, error) {
+	switch dp := DriftPolicy(s); dp {
+	case DRIFT_FAIL, DRIFT_IGNORE, DRIFT_SHARED:
+		return dp, nil
+	}
+	return "", fmt.Errorf(
+		"invalid drift policy '%s': must be fail, ignore or shared", s)
+}
+
+// differences between a response's headers & a table's columns
+type Drift struct {
+	Tbl     string
+	New     []string // in the response, not in the table
+	Missing []string // in the table, not in the response
+	Order   bool     // shared columns come back in a different order
+}
+
+func (d Drift) Any() bool {
+	return len(d.New) > 0 || len(d.Missing) > 0 || d.Order
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf(
+		"schema drift in %s: new columns %v | missing columns %v | order changed: %v",
+		d.Tbl, d.New, d.Missing, d.Order)
+}
+
+// compare (case insensitive) api headers against a table's columns
+func CompareCols(tbl string, hdrs, tblCols []string) Drift {
+	d := Drift{Tbl: tbl}
+	var hShared, tShared []string
+	for _, h := range hdrs {
+		if slices.Contains(tblCols, strings.ToLower(h)) {
+			hShared = append(hShared, strings.ToLower(h))
+		} else {
+			d.New = append(d.New, strings.ToLower(h))
+		}
+	}
+	for _, c := range tblCols {
+		if slices.ContainsFunc(hdrs, func(h string) bool {
+			return strings.EqualFold(h, c)
+		}) {
+			tShared = append(tShared, c)
+		} else {
+			d.Missing = append(d.Missing, c)
+		}
+	}
+	d.Order = !slices.Equal(hShared, tShared)
+	return d
+}
+
+// columns of a schema.table in ordinal order from information_schema
+func TableCols(ctx context.Context, db *sql.DB, tbl string) ([]string, error) {
+	schema, name, ok := strings.Cut(tbl, ".")
+	if !ok {
+		schema, name = "public", tbl
+	}
+	rows, err := db.QueryContext(ctx, `
+		select column_name from information_schema.columns
+		where table_schema = $1 and table_name = $2
+		order by ordinal_position`, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s not found in information_schema", tbl)
+	}
+	return cols, nil
+}
+
+// tbl's columns, looked up once per run & cached in cnf.tblCols
+func (cnf *Conf) tableCols(ctx context.Context, tbl string) ([]string, error) {
+	cnf.tblMu.Lock()
+	defer cnf.tblMu.Unlock()
+	if tc, ok := cnf.tblCols[tbl]; ok {
+		return tc, nil
+	}
+	tc, err := TableCols(ctx, cnf.DB, tbl)
+	if err != nil {
+		return nil, fmt.Errorf("error getting columns for %s: %w", tbl, err)
+	}
+	if cnf.tblCols == nil {
+		cnf.tblCols = make(map[string][]string)
+	}
+	cnf.tblCols[tbl] = tc
+	return tc, nil
+}
+
+/*
+compare the response's columns to tbl before inserting, logs & records any
+drift in cnf.Warns, then per cnf.Drift (pol overrides it if not empty) returns
+the columns & rows to insert or an error
+*/
+func (cnf *Conf) checkDrift(
+	ctx context.Context, tbl string, pol DriftPolicy, cols []string, rows [][]any,
+) ([]string, [][]any, error) {
+	if pol == "" {
+		pol = cnf.Drift
+	}
+	if pol == "" {
+		pol = DRIFT_IGNORE
+	}
+	tc, err := cnf.tableCols(ctx, tbl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d := CompareCols(tbl, cols, tc)
+	if !d.Any() {
+		return cols, rows, nil
+	}
+	cnf.L.WriteLog(fmt.Sprintf("WARNING: %v | policy: %s", d, pol))
+	if !slices.Contains(cnf.Warns, d.String()) { // once per run, not per call
+		cnf.Warns = append(cnf.Warns, d.String())
+	}
+
+	switch {
+	case pol == DRIFT_FAIL && (len(d.New) > 0 || len(d.Missing) > 0),
+		pol == DRIFT_IGNORE && len(d.Missing) > 0:
+		return nil, nil, fmt.Errorf("%v | policy: %s", d, pol)
+	case len(d.New) == 0: // only missing or order changes, insert as is
+		return cols, rows, nil
+	}
+
+	// drop the new columns from the insert
+	var keep []int
+	var kCols []string
+	for i, c := range cols {
+		if !slices.Contains(d.New, strings.ToLower(c)) {
+			keep = append(keep, i)
+			kCols = append(kCols, c)
+		}
+	}
+	if len(kCols) == 0 {
+		return nil, nil, fmt.Errorf("%v | no shared columns to insert", d)
+	}
+	kRows := make([][]any, len(rows))
+	for r, row := range rows {
+		kRows[r] = make([]any, len(keep))
+		for j, i := range keep {
+			kRows[r][j] = row[i]
+		}
+	}
+	return kCols, kRows, nil
+}
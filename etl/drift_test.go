@@ -0,0 +1,165 @@
+package etl
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompareCols(t *testing.T) {
+	tblCols := []string{"snap_date", "team_id", "player", "pts"}
+	tests := []struct {
+		name    string
+		hdrs    []string
+		consts  []string // added with WithCol like SetTbl.Consts
+		newCols []string
+		missing []string
+		order   bool
+	}{
+		{"match, case insensitive",
+			[]string{"SNAP_DATE", "TEAM_ID", "Player", "pts"}, nil,
+			nil, nil, false},
+		{"new api column",
+			[]string{"SNAP_DATE", "TEAM_ID", "PLAYER", "PTS", "AST"}, nil,
+			[]string{"ast"}, nil, false},
+		{"missing column",
+			[]string{"SNAP_DATE", "TEAM_ID", "PLAYER"}, nil,
+			nil, []string{"pts"}, false},
+		{"renamed column is new & missing",
+			[]string{"SNAP_DATE", "TEAM_ID", "PLAYER_NAME", "PTS"}, nil,
+			[]string{"player_name"}, []string{"player"}, false},
+		{"order change",
+			[]string{"SNAP_DATE", "PLAYER", "TEAM_ID", "PTS"}, nil,
+			nil, nil, true},
+		// consts are prepended before the compare, the api never sends them
+		{"const column isn't missing",
+			[]string{"TEAM_ID", "PLAYER", "PTS"}, []string{"SNAP_DATE"},
+			nil, nil, false},
+		{"const column already in the response isn't added twice",
+			[]string{"SNAP_DATE", "TEAM_ID", "PLAYER", "PTS"},
+			[]string{"snap_date"}, nil, nil, false},
+		{"without the const it's missing",
+			[]string{"TEAM_ID", "PLAYER", "PTS"}, nil,
+			nil, []string{"snap_date"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := ResultSet{Name: "X", Headers: tt.hdrs}
+			for _, c := range tt.consts {
+				rs = rs.WithCol(c, "2025-01-16")
+			}
+			d := CompareCols("intake.x", rs.Headers, tblCols)
+			if !slices.Equal(d.New, tt.newCols) ||
+				!slices.Equal(d.Missing, tt.missing) || d.Order != tt.order {
+				t.Errorf("got %v, want new %v missing %v order %v",
+					d, tt.newCols, tt.missing, tt.order)
+			}
+			wantAny := tt.newCols != nil || tt.missing != nil || tt.order
+			if d.Any() != wantAny {
+				t.Errorf("Any() = %v, want %v", d.Any(), wantAny)
+			}
+		})
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	hdrs := []string{"TEAM_ID", "NEW_COL", "PTS"}
+	rows := [][]any{{1, "x", 30}, {2, "y", 20}}
+	tests := []struct {
+		pol  DriftPolicy
+		tbl  []string
+		cols []string
+		err  bool
+	}{
+		{"", []string{"team_id", "pts"}, []string{"TEAM_ID", "PTS"}, false},
+		{DRIFT_FAIL, []string{"team_id", "pts"}, nil, true},
+		{DRIFT_IGNORE, []string{"team_id", "pts"},
+			[]string{"TEAM_ID", "PTS"}, false},
+		{DRIFT_IGNORE, []string{"team_id", "pts", "reb"}, nil, true},
+		{DRIFT_SHARED, []string{"team_id", "pts", "reb"},
+			[]string{"TEAM_ID", "PTS"}, false},
+		{DRIFT_SHARED, []string{"reb"}, nil, true}, // nothing shared
+	}
+	for _, tt := range tests {
+		fdb, db := newFakeDB(t)
+		fdb.Cols["intake.x"] = tt.tbl
+		cnf := testConf(t, db, "")
+		cols, kRows, err := cnf.checkDrift(
+			context.Background(), "intake.x", tt.pol, hdrs, rows)
+		if (err != nil) != tt.err {
+			t.Fatalf("policy %q, table %v: err = %v, want err %v",
+				tt.pol, tt.tbl, err, tt.err)
+		}
+		if !slices.Equal(cols, tt.cols) {
+			t.Errorf("policy %q, table %v: cols = %v, want %v",
+				tt.pol, tt.tbl, cols, tt.cols)
+		}
+		if cols != nil && (len(kRows) != 2 || len(kRows[0]) != len(cols)) {
+			t.Errorf("policy %q: rows = %v, want 2 rows of %d values",
+				tt.pol, kRows, len(cols))
+		}
+		if len(cnf.Warns) != 1 {
+			t.Errorf("policy %q: warns = %v, want the drift once", tt.pol, cnf.Warns)
+		}
+	}
+}
+
+/*
+sets loaded with Consts against the real intake ddl: the const columns count
+as sent, api headers are every other table column
+*/
+func TestConstsDrift(t *testing.T) {
+	snap := time.Date(2025, 1, 16, 0, 0, 0, 0, ET)
+	sets := append(RosterSets(snap), StandingsTbl(snap), PlayerInfoTbl(snap))
+	for _, st := range sets {
+		t.Run(st.Tbl, func(t *testing.T) {
+			fdb, db := newFakeDB(t)
+			cnf := testConf(t, db, "")
+			tc := fdb.Cols[st.Tbl]
+			rs := ResultSet{Name: st.Set}
+			for _, c := range tc {
+				if !slices.ContainsFunc(st.Consts, func(p Pair) bool {
+					return strings.EqualFold(p.Key, c)
+				}) {
+					rs.Headers = append(rs.Headers, strings.ToUpper(c))
+				}
+			}
+			row := make([]any, len(rs.Headers))
+			rs.RowSet = [][]any{row}
+			if err := LoadSet(context.Background(), cnf, rs, st); err != nil {
+				t.Fatalf("LoadSet: %v", err)
+			}
+			if len(cnf.Warns) > 0 {
+				t.Errorf("drift with consts %v: %v", st.Consts, cnf.Warns)
+			}
+			if exs := fdb.execs("insert into " + st.Tbl); len(exs) != 1 ||
+				len(exs[0].Args) != len(tc) {
+				t.Errorf("inserts = %v, want 1 of %d values", exs, len(tc))
+			}
+		})
+	}
+}
+
+// the column cache is safe to fill from concurrent loaders
+func TestTableColsConcurrent(t *testing.T) {
+	_, db := newFakeDB(t)
+	cnf := testConf(t, db, "")
+	var wg sync.WaitGroup
+	for _, tbl := range []string{"intake.player", "intake.wplayer",
+		"intake.gm_team", "intake.player", "intake.gm_team"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cnf.tableCols(context.Background(), tbl); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(cnf.tblCols) != 3 {
+		t.Errorf("%d tables cached, want 3", len(cnf.tblCols))
+	}
+}
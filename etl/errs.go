@@ -0,0 +1,82 @@
+package etl
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+errors for bad api responses, each carries the request url & the start of the
+response body so the log shows exactly what the api sent back
+check with errors.As, e.g.
+	var se *StatusErr
+	if errors.As(err, &se) && se.Code == 403 {...}
+*/
+
+// fields shared by every api response error
+type APIErr struct {
+	URL  string
+	Snip string // first SNIP_LEN bytes of the body, whitespace collapsed
+}
+
+const SNIP_LEN int = 300
+
+// non 2xx response
+type StatusErr struct {
+	APIErr
+	Code int
+}
+
+func (se *StatusErr) Error() string {
+	return fmt.Sprintf("%d %s from %s | body: %s",
+		se.Code, http.StatusText(se.Code), se.URL, se.Snip)
+}
+
+// body isn't json (html block page, truncated body, etc)
+type BodyErr struct {
+	APIErr
+	Err error // error from json decoder
+}
+
+func (be *BodyErr) Error() string {
+	return fmt.Sprintf("non-JSON response body from %s: %v | body: %s",
+		be.URL, be.Err, be.Snip)
+}
+
+func (be *BodyErr) Unwrap() error { return be.Err }
+
+// valid json but no result sets
+type EmptyErr struct {
+	APIErr
+}
+
+func (ee *EmptyErr) Error() string {
+	return fmt.Sprintf("response from %s contains no result sets | body: %s",
+		ee.URL, ee.Snip)
+}
+
+// response doesn't contain the result set a loader expects
+type SetErr struct {
+	APIErr
+	Name string   // expected result set
+	Have []string // result sets in the response
+}
+
+func (se *SetErr) Error() string {
+	return fmt.Sprintf("result set '%s' missing from %s response, got %v | body: %s",
+		se.Name, se.URL, se.Have, se.Snip)
+}
+
+func newAPIErr(url string, body []byte) APIErr {
+	return APIErr{URL: url, Snip: snip(body)}
+}
+
+// first SNIP_LEN bytes of body on one line
+func snip(body []byte) string {
+	s := strings.Join(strings.Fields(string(body[:min(len(body), SNIP_LEN)])), " ")
+	if len(body) > SNIP_LEN {
+		s += "..."
+	}
+	return s
+}
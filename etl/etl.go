@@ -1,9 +1,10 @@
 package etl
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"sync"
 	"time"
 
 	"github.com/jdetok/golib/errd"
@@ -16,55 +17,287 @@ type Conf struct {
 	DB     *sql.DB
 	RowCnt int64 // row counter
 	Errs   []string
+	Warns  []string    // non fatal issues for the run summary, e.g. drift
+	Events []string    // roster moves etc for the run summary
+	F      Fetcher     // gets api response bodies, nil uses a plain HTTPFetcher
+	Brk    *Breaker    // same breaker as the HTTPFetcher, stops the run if open
+	Drift  DriftPolicy // response vs table column mismatches, default ignore
+	STypes []SznType   // season types to fetch, default DefaultSznTypes
+	Clock  Clock       // game day & season resolution, nil uses the system clock
+	Pinned bool        // -date run for a past day, live data steps are skipped
+
+	tblMu   sync.Mutex          // guards tblCols
+	tblCols map[string][]string // table columns cache for checkDrift
+}
+
+// cnf.F, or an HTTPFetcher with no retry/limit if it wasn't set
+func (cnf *Conf) fetcher() Fetcher {
+	if cnf.F == nil {
+		cnf.F = &HTTPFetcher{L: &cnf.L, Brk: cnf.Brk}
+	}
+	return cnf.F
 }
 
-func RunNightlyETL(cnf Conf) error {
+/*
+refreshes the schedule first, if no games were scheduled yesterday the rest of
+the api calls are skipped
+a failed schedule fetch runs everything as before
+a pinned run (-date) loads only that day's games, players, rosters, standings
+& player info are live data the api can't give as of a past day
+*/
+func RunNightlyETL(ctx context.Context, cnf *Conf) error {
 	e := errd.InitErr()
+	yesterday := Yesterday(cnf.Now())
+	cnf.L.WriteLog(fmt.Sprintf("nightly ETL for %s (ET) | seasons: %s",
+		yesterday, ActiveSzns(DefaultLeagues(), cnf.NightlyDay())))
 
-	if err := CrntPlayersETL(cnf); err != nil {
-		e.Msg = "error with current players ETL"
+	// lg.league rows for every registry league before anything references them
+	if err := SyncLeagues(ctx, cnf); err != nil {
+		e.Msg = "error syncing lg.league with the league registry"
 		cnf.L.WriteLog(e.Msg)
 		return e.BuildErr(err)
 	}
 
-	if err := GLogDailyETL(&cnf); err != nil {
+	// schedule: statuses for yesterday's games, skip the run on off days
+	var sched bool = true
+	if err := SchedDailyETL(ctx, cnf); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		sched = false
+		e.Msg = "error with nightly schedule ETL, running without the schedule"
+		cnf.L.WriteLog(e.Msg)
+		cnf.Errs = append(cnf.Errs, e.Msg)
+	}
+	if sched {
+		gms, err := SchedGames(ctx, cnf, yesterday, false)
+		if err != nil {
+			e.Msg = fmt.Sprintf("error reading %s schedule", yesterday)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		if len(gms) == 0 {
+			cnf.L.WriteLog(fmt.Sprintf(
+				"\n====  no games scheduled for %s, skipping nightly ETL",
+				yesterday))
+			return nil
+		}
+		cnf.L.WriteLog(fmt.Sprintf("%d games scheduled for %s",
+			len(gms), yesterday))
+	}
+
+	var live bool = !cnf.Pinned
+	if !live {
+		cnf.L.WriteLog(fmt.Sprintf("run pinned to %s, skipping players, "+
+			"rosters, standings & player info", yesterday))
+	}
+
+	if live {
+		if err := CrntPlayersETL(ctx, cnf); err != nil {
+			e.Msg = "error with current players ETL"
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+
+		// today's roster & coach snapshots, lg.sp_plr_crnt uses the latest
+		if err := RostersETL(ctx, cnf); err != nil {
+			e.Msg = "error with nightly team roster ETL"
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+
+	if err := GLogDailyETL(ctx, cnf); err != nil {
 		e.Msg = "error with nightly game log ETL"
 		cnf.L.WriteLog(e.Msg)
 		return e.BuildErr(err)
 	}
 
+	// scheduled games the game logs didn't return, logged & in the summary
+	if sched {
+		if err := LogMissingGames(ctx, cnf, yesterday); err != nil {
+			e.Msg = "error checking schedule for missing games"
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+
+	// box score summaries for yesterday's games just loaded
+	if err := GameSumsETL(ctx, cnf, GameFilter{
+		Date: yesterday}); err != nil {
+		e.Msg = "error with nightly box score summary ETL"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// advanced, four factors & usage box scores for yesterday's games
+	if err := AdvBoxesETL(ctx, cnf, GameFilter{
+		Date: yesterday}); err != nil {
+		e.Msg = "error with nightly advanced box score ETL"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// play by play for yesterday's games
+	if err := PBPsETL(ctx, cnf, GameFilter{
+		Date: yesterday}); err != nil {
+		e.Msg = "error with nightly play by play ETL"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// today's standings snapshot for both leagues
+	if live {
+		if err := StandingsDailyETL(ctx, cnf); err != nil {
+			e.Msg = "error with nightly standings ETL"
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+
+	// shot chart locations for yesterday's games
+	if err := ShotDailyETL(ctx, cnf); err != nil {
+		e.Msg = "error with nightly shot chart ETL"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// bio details for players new to lg.plr or not refreshed recently
+	if live {
+		if err := PlayerInfosETL(ctx, cnf, false); err != nil {
+			e.Msg = "error with nightly player info ETL"
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+
 	cnf.L.WriteLog(fmt.Sprintf(
 		"\n====  finished with nightly ETL | total rows affected: %d", cnf.RowCnt))
 	return nil
 }
 
-func RunSeasonETL(cnf Conf, startY, endY string) error {
+/*
+game logs for every league in lgs between from & to (game days, inclusive),
+for catching up after missed nightly runs, then today's current players
+*/
+func RunBackfillETL(
+	ctx context.Context, cnf *Conf, lgs []League, from, to time.Time,
+) error {
 	e := errd.InitErr()
+	dates := fmt.Sprintf("%s - %s",
+		from.In(ET).Format("01/02/2006"), to.In(ET).Format("01/02/2006"))
+
+	if err := SyncLeagues(ctx, cnf); err != nil {
+		e.Msg = "error syncing lg.league with the league registry"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// player & team game logs split by each league's seasons
+	if err := GLogRangeETL(ctx, cnf, lgs, from, to); err != nil {
+		e.Msg = fmt.Sprintf("error with game log backfill for %s", dates)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// current players, team changes are recorded as transactions
+	if err := CrntPlayersETL(ctx, cnf); err != nil {
+		e.Msg = "error with current players ETL during backfill"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
 
-	szns, err := SznBSlice(cnf.L, startY, endY)
+	cnf.L.WriteLog(fmt.Sprintf(
+		"\n====  finished with backfill ETL for %s | total rows affected: %d",
+		dates, cnf.RowCnt))
+	return nil
+}
+
+func RunSeasonETL(ctx context.Context, cnf *Conf, startY, endY string) error {
+	e := errd.InitErr()
+
+	if err := SyncLeagues(ctx, cnf); err != nil {
+		e.Msg = "error syncing lg.league with the league registry"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	st, err := ParseSeason(startY)
 	if err != nil {
-		e.Msg = "error making seasons string"
+		e.Msg = "error parsing start season"
 		cnf.L.WriteLog(e.Msg)
-		log.Fatal(e.BuildErr(err))
+		return e.BuildErr(err)
+	}
+	en, err := ParseSeason(endY)
+	if err != nil {
+		e.Msg = "error parsing end season"
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
 	}
 
-	for _, s := range szns {
+	// newest season first
+	szns := SeasonRange(en, st)
+	for _, szn := range szns {
+		s := szn.String()
 		sra := cnf.RowCnt // capture row count at start of each season
 		stT := time.Now()
 
 		// players etl for season
-		if err := SznPlayersETL(cnf, "1", s); err != nil {
+		if err := SznPlayersETL(ctx, cnf, "1", s); err != nil {
 			e.Msg = fmt.Sprint("error getting players for ", s)
 			cnf.L.WriteLog(e.Msg)
 			fmt.Println(e.BuildErr(err))
 		}
 
 		// get team and player game logs for the season
-		err = GLogSeasonETL(&cnf, s)
+		err = GLogSeasonETL(ctx, cnf, s)
 		if err != nil {
 			e.Msg = fmt.Sprint("error inserting data for ", s)
 			cnf.L.WriteLog(e.Msg)
 			fmt.Println(e.BuildErr(err))
+		}
+
+		// box score summaries for the season's games
+		if err := GameSumsETL(ctx, cnf, GameFilter{Szn: szn.Yr()}); err != nil {
+			e.Msg = fmt.Sprint("error getting box score summaries for ", s)
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+		}
+
+		// advanced box scores for the season's games
+		if err := AdvBoxesETL(ctx, cnf, GameFilter{Szn: szn.Yr()}); err != nil {
+			e.Msg = fmt.Sprint("error getting advanced box scores for ", s)
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+		}
+
+		// play by play for the season's games
+		if err := PBPsETL(ctx, cnf, GameFilter{Szn: szn.Yr()}); err != nil {
+			e.Msg = fmt.Sprint("error getting play by play for ", s)
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+		}
+
+		// shot chart locations for the season
+		if err := ShotSeasonETL(ctx, cnf, DefaultLeagues(), s); err != nil {
+			e.Msg = fmt.Sprint("error getting shot charts for ", s)
+			cnf.L.WriteLog(e.Msg)
+			fmt.Println(e.BuildErr(err))
+		}
+
+		// interrupted (SIGINT/SIGTERM), stop before starting the next season
+		if ctx.Err() != nil {
+			cnf.L.WriteLog(fmt.Sprintf(
+				"season ETL cancelled during %s season", s))
+			return ctx.Err()
+		}
+
+		// api is blocking us, stop instead of failing every remaining season
+		if cnf.Brk.Open() {
+			e.Msg = fmt.Sprintf(
+				"circuit breaker open during %s season, stopping season ETL", s)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(ErrBreakerOpen)
 		} // log finished with season etl
 		cnf.L.WriteLog(fmt.Sprint(
 			fmt.Sprintf("====  finished with %s season ETL after %v",
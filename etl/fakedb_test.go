@@ -0,0 +1,226 @@
+package etl
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jdetok/golib/logd"
+)
+
+/*
+in memory stand-in for postgres so the etl flows can run offline
+  - information_schema column lookups are answered from the intake & lg ddl
+  - other queries return the rows set in Rows for a substring of the query
+  - every exec is recorded in Execs, inserts report one affected row per row
+  - every other query is recorded in Qrys
+*/
+type fakeDB struct {
+	mu    sync.Mutex
+	Cols  map[string][]string         // schema.table: columns
+	Types map[string]string           // schema.table.column: ddl type
+	Rows  map[string][][]driver.Value // query substring: rows returned
+	Execs []fakeExec
+	Qrys  []fakeExec
+}
+
+type fakeExec struct {
+	Qry  string
+	Args []any
+}
+
+var fakeDBs sync.Map // dsn: *fakeDB
+
+func init() {
+	sql.Register("etlfake", fakeDriver{})
+}
+
+// new fake db & a *sql.DB connected to it, closed at the end of the test
+func newFakeDB(t *testing.T) (*fakeDB, *sql.DB) {
+	t.Helper()
+	fdb := &fakeDB{
+		Cols:  make(map[string][]string),
+		Types: make(map[string]string),
+		Rows:  make(map[string][][]driver.Value),
+	}
+	for _, f := range []string{"d_intake.sql", "e_lg.sql"} {
+		cols, types := ddlCols(t, filepath.Join("..", "sql", "d_tbl", f))
+		maps.Copy(fdb.Cols, cols)
+		maps.Copy(fdb.Types, types)
+	}
+	fakeDBs.Store(t.Name(), fdb)
+	db, err := sql.Open("etlfake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBs.Delete(t.Name())
+	})
+	return fdb, db
+}
+
+// execs whose statement starts with prefix, e.g. "insert into intake.gm_team"
+func (fdb *fakeDB) execs(prefix string) []fakeExec {
+	fdb.mu.Lock()
+	defer fdb.mu.Unlock()
+	var out []fakeExec
+	for _, ex := range fdb.Execs {
+		if strings.HasPrefix(strings.TrimSpace(ex.Qry), prefix) {
+			out = append(out, ex)
+		}
+	}
+	return out
+}
+
+/*
+columns of every "create table schema.x (...)" in a ddl file & each column's
+type keyed by schema.x.column, e.g. "bigint" or "varchar(255)"
+*/
+func ddlCols(
+	t *testing.T, path string,
+) (map[string][]string, map[string]string) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cols := make(map[string][]string)
+	types := make(map[string]string)
+	var tbl string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		ln := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(ln, "create table "):
+			tbl = strings.Fields(ln)[2]
+		case tbl == "":
+		case strings.HasPrefix(ln, ");"):
+			tbl = ""
+		case ln == "", strings.HasPrefix(ln, "--"),
+			strings.HasPrefix(ln, "primary key"):
+		default:
+			fs := strings.Fields(strings.TrimSuffix(ln, ","))
+			cols[tbl] = append(cols[tbl], fs[0])
+			types[tbl+"."+fs[0]] = strings.TrimSuffix(fs[1], ",")
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return cols, types
+}
+
+// conf for offline runs: fake db, fixtures dir, logger writing to a temp file
+func testConf(t *testing.T, db *sql.DB, dir string) *Conf {
+	t.Helper()
+	lf := filepath.Join(t.TempDir(), "test.log")
+	if err := os.WriteFile(lf, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Conf{
+		L:  logd.Logger{LogF: lf},
+		DB: db,
+		F:  &FileFetcher{Dir: dir},
+	}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	v, ok := fakeDBs.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("no fake db %s", dsn)
+	}
+	return &fakeConn{v.(*fakeDB)}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake db: prepare not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake db: transactions not supported")
+}
+
+// args are recorded as passed, e.g. json.Number stays json.Number
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *fakeConn) ExecContext(
+	ctx context.Context, qry string, args []driver.NamedValue,
+) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	ex := fakeExec{Qry: qry}
+	for _, a := range args {
+		ex.Args = append(ex.Args, a.Value)
+	}
+	c.db.Execs = append(c.db.Execs, ex)
+
+	n := int64(len(args)) // update ... in ($1, $2): one row per arg
+	if strings.HasPrefix(qry, "insert into") {
+		colList, _, _ := strings.Cut(qry[strings.Index(qry, "(")+1:], ")")
+		n /= int64(strings.Count(colList, ",") + 1)
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (c *fakeConn) QueryContext(
+	ctx context.Context, qry string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if strings.Contains(qry, "information_schema.columns") {
+		tbl := fmt.Sprintf("%v.%v", args[0].Value, args[1].Value)
+		r := &fakeRows{cols: []string{"column_name"}}
+		for _, col := range c.db.Cols[tbl] {
+			r.rows = append(r.rows, []driver.Value{col})
+		}
+		return r, nil
+	}
+	q := fakeExec{Qry: qry}
+	for _, a := range args {
+		q.Args = append(q.Args, a.Value)
+	}
+	c.db.Qrys = append(c.db.Qrys, q)
+	for k, rows := range c.db.Rows {
+		if strings.Contains(qry, k) && len(rows) > 0 {
+			r := &fakeRows{rows: rows}
+			for i := range rows[0] {
+				r.cols = append(r.cols, fmt.Sprintf("c%d", i))
+			}
+			return r, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+	i    int
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
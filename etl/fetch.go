@@ -0,0 +1,202 @@
+package etl
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jdetok/golib/logd"
+)
+
+/*
+gets the raw response body for a request, assign one to Conf.F
+every etl function gets its data through cnf.F so the flows can run against
+  - HTTPFetcher: stats.nba.com (or the -api-base override)
+  - FileFetcher: json saved to disk, e.g. by RecFetcher
+  - MemFetcher: bodies held in memory, for tests
+*/
+type Fetcher interface {
+	Fetch(ctx context.Context, gr GetReq) ([]byte, error)
+}
+
+/*
+optional for a Fetcher: where it actually gets a request from, e.g. the
+-api-base host or a fixture file, used in error messages
+*/
+type Locator interface {
+	Locate(gr GetReq) string
+}
+
+// url/file cnf.F gets gr from, gr's stats.nba.com url if it isn't a Locator
+func (cnf *Conf) reqURL(gr GetReq) string {
+	if l, ok := cnf.fetcher().(Locator); ok {
+		return l.Locate(gr)
+	}
+	return gr.MakeFulLURL()
+}
+
+// key identifying a request regardless of host, e.g. /stats/x?A=1&B=2
+func (gr *GetReq) Key() string {
+	return gr.makeQryStr(gr.Endpoint)
+}
+
+// requests the api over http with retry, rate limit & circuit breaker
+type HTTPFetcher struct {
+	L      *logd.Logger // usually &cnf.L
+	Client *http.Client // nil uses http.DefaultClient
+	Base   *url.URL     // overrides scheme & host of every request if set
+	Retry  RetryPolicy  // retry/backoff for api requests
+	Brk    *Breaker     // shared across the run, nil never trips
+	Lim    *Limiter     // paces every api request, nil doesn't limit
+}
+
+func (hf *HTTPFetcher) Fetch(ctx context.Context, gr GetReq) ([]byte, error) {
+	return gr.BodyFromReq(ctx, hf)
+}
+
+// url with the -api-base scheme & host if set
+func (hf *HTTPFetcher) Locate(gr GetReq) string {
+	return gr.withBase(hf.Base).MakeFulLURL()
+}
+
+/*
+reads response bodies saved under Dir, the file for a request is
+Dir/<endpoint>/<sha1 of the query string>.json, e.g.
+Dir/stats/leaguegamelog/3f0c...e1.json, the query strings (shotchartdetail's
+is over 300 bytes) are too long for file names
+Dir/<endpoint>/index.tsv lists each file's query string (<hash>\t<query>)
+when that doesn't exist Dir/<endpoint>.json is used for any params
+*/
+type FileFetcher struct {
+	Dir string
+}
+
+func (ff *FileFetcher) Fetch(ctx context.Context, gr GetReq) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p := ff.path(gr)
+	body, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		body, err = os.ReadFile(filepath.Join(ff.Dir,
+			filepath.FromSlash(strings.TrimPrefix(gr.Endpoint, "/"))+".json"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no saved response for %s in %s: %w",
+			gr.Key(), ff.Dir, err)
+	}
+	return body, nil
+}
+
+// the fixture file for gr (the endpoint fallback isn't checked)
+func (ff *FileFetcher) Locate(gr GetReq) string {
+	return ff.path(gr)
+}
+
+// query string a request's file is named by, e.g. LeagueID=00&Season=2024-25
+func fixtureQry(gr GetReq) string {
+	return strings.TrimPrefix(gr.makeQryStr(""), "?")
+}
+
+// file a request's body is saved to/read from
+func (ff *FileFetcher) path(gr GetReq) string {
+	sum := sha1.Sum([]byte(fixtureQry(gr)))
+	return filepath.Join(ff.Dir,
+		filepath.FromSlash(strings.TrimPrefix(gr.Endpoint, "/")),
+		hex.EncodeToString(sum[:])+".json")
+}
+
+/*
+passes requests to F & saves every body it returns to Dir for FileFetcher
+new files are added to their endpoint dir's index.tsv
+*/
+type RecFetcher struct {
+	F   Fetcher
+	Dir string
+	mu  sync.Mutex // one writer at a time for the index files
+}
+
+func (rf *RecFetcher) Fetch(ctx context.Context, gr GetReq) ([]byte, error) {
+	body, err := rf.F.Fetch(ctx, gr)
+	if err != nil {
+		return nil, err
+	}
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	p := (&FileFetcher{Dir: rf.Dir}).path(gr)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, fmt.Errorf("error creating dir for %s: %w", p, err)
+	}
+	_, err = os.Stat(p)
+	isNew := errors.Is(err, fs.ErrNotExist)
+	if err := os.WriteFile(p, body, 0644); err != nil {
+		return nil, fmt.Errorf("error saving response to %s: %w", p, err)
+	}
+	if isNew {
+		if err := addToIndex(p, fixtureQry(gr)); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// F's location, the saved file is only a copy
+func (rf *RecFetcher) Locate(gr GetReq) string {
+	if l, ok := rf.F.(Locator); ok {
+		return l.Locate(gr)
+	}
+	return gr.MakeFulLURL()
+}
+
+// append "<hash>\t<query>" for a saved file p to its dir's index.tsv
+func addToIndex(p, qry string) error {
+	idx := filepath.Join(filepath.Dir(p), "index.tsv")
+	f, err := os.OpenFile(idx, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", idx, err)
+	}
+	defer f.Close()
+	hash := strings.TrimSuffix(filepath.Base(p), ".json")
+	if _, err := fmt.Fprintf(f, "%s\t%s\n", hash, qry); err != nil {
+		return fmt.Errorf("error writing to %s: %w", idx, err)
+	}
+	return nil
+}
+
+/*
+in memory fake, bodies & errors are keyed by gr.Key() or just gr.Endpoint to
+match any params, every request received is appended to Reqs
+*/
+type MemFetcher struct {
+	Bodies map[string][]byte
+	Errs   map[string]error
+	mu     sync.Mutex
+	Reqs   []GetReq
+}
+
+func (mf *MemFetcher) Fetch(ctx context.Context, gr GetReq) ([]byte, error) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	mf.Reqs = append(mf.Reqs, gr)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{gr.Key(), gr.Endpoint} {
+		if err, ok := mf.Errs[k]; ok {
+			return nil, err
+		}
+		if body, ok := mf.Bodies[k]; ok {
+			return body, nil
+		}
+	}
+	return nil, fmt.Errorf("no body for %s", gr.Key())
+}
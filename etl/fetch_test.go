@@ -0,0 +1,135 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// responses recorded through RecFetcher read back the same with FileFetcher
+func TestRecFetcherRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	shots := ShotChartReq("00", "2024-25", "Regular Season", "", "")
+	plrs := PlayerReq("1", "00", "2024-25")
+	mf := &MemFetcher{Bodies: map[string][]byte{
+		shots.Key():   []byte(`{"resultSets":[{"name":"Shot_Chart_Detail"}]}`),
+		plrs.Endpoint: []byte(`{"resultSets":[{"name":"CommonAllPlayers"}]}`),
+	}}
+	rf := &RecFetcher{F: mf, Dir: dir}
+	ff := &FileFetcher{Dir: dir}
+
+	// shotchartdetail's query string is too long for a file name
+	if n := len(fixtureQry(shots)); n <= 255 {
+		t.Fatalf("shotchartdetail query is %d bytes, want > 255", n)
+	}
+	for _, r := range []GetReq{shots, plrs, shots} { // shots twice
+		want, err := rf.Fetch(context.Background(), r)
+		if err != nil {
+			t.Fatalf("RecFetcher %s: %v", r.Endpoint, err)
+		}
+		got, err := ff.Fetch(context.Background(), r)
+		if err != nil {
+			t.Fatalf("FileFetcher %s: %v", r.Endpoint, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s: read %s, saved %s", r.Endpoint, got, want)
+		}
+		if base := filepath.Base(ff.path(r)); len(base) != 45 {
+			t.Errorf("%s file name %s isn't a sha1 hash", r.Endpoint, base)
+		}
+	}
+	if len(mf.Reqs) != 3 {
+		t.Errorf("MemFetcher got %d requests, want 3", len(mf.Reqs))
+	}
+
+	// one index line per saved file, with the query the hash stands for
+	idx, err := os.ReadFile(filepath.Join(dir, "stats", "shotchartdetail",
+		"index.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.TrimSuffix(filepath.Base(ff.path(shots)), ".json") +
+		"\t" + fixtureQry(shots) + "\n"
+	if string(idx) != want {
+		t.Errorf("index.tsv = %q, want %q", idx, want)
+	}
+}
+
+func TestFileFetcher(t *testing.T) {
+	dir := t.TempDir()
+	ep := filepath.Join(dir, "stats", "leaguegamelog.json")
+	if err := os.MkdirAll(filepath.Dir(ep), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ep, []byte(`{"any":"params"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ff := &FileFetcher{Dir: dir}
+
+	tests := []struct {
+		name string
+		r    GetReq
+		want string
+		err  bool
+	}{
+		{"endpoint fallback",
+			GameLogReqNew("00", "2024-25", "Playoffs", "T", "", ""),
+			`{"any":"params"}`, false},
+		{"no saved response", PlayerReq("1", "00", "2024-25"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ff.Fetch(context.Background(), tt.r)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want err %v", err, tt.err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemFetcherErrs(t *testing.T) {
+	boom := errors.New("boom")
+	r := PlayerReq("1", "10", "2024")
+	mf := &MemFetcher{Errs: map[string]error{r.Endpoint: boom}}
+	if _, err := mf.Fetch(context.Background(), r); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want %v", err, boom)
+	}
+}
+
+// errors name the -api-base host that was called, not stats.nba.com
+func TestRequestRespErrURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("<html>blocked</html>"))
+		}))
+	defer srv.Close()
+	base, err := ParseBase(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, db := newFakeDB(t)
+	cnf := testConf(t, db, "")
+	cnf.F = &RecFetcher{
+		F:   &HTTPFetcher{L: &cnf.L, Base: base},
+		Dir: t.TempDir(),
+	}
+	_, err = RequestResp(context.Background(), cnf,
+		PlayerReq("1", "00", "2024-25"))
+	var be *BodyErr
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, want *BodyErr", err)
+	}
+	if !strings.HasPrefix(be.URL, srv.URL+"/stats/commonallplayers?") {
+		t.Errorf("error url = %s, want %s/stats/commonallplayers?...",
+			be.URL, srv.URL)
+	}
+}
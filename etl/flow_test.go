@@ -0,0 +1,367 @@
+package etl
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+hand written responses in the layout RecFetcher saves (-record), small enough
+to check every row loaded
+*/
+const FIXTURES = "testdata/fixtures"
+
+// nightly run on the morning of 01/16/2025 ET, loading 01/15/2025
+var fixtureClock = FixedClock{time.Date(2025, 1, 16, 6, 0, 0, 0, ET)}
+
+func TestGameLogETLOffline(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+
+	tests := []struct {
+		tbl     string
+		primKey string
+		plTm    string
+		rows    int
+		cols    int
+		id      int64 // first row's team/player id
+	}{
+		{"intake.gm_team", "game_id, team_id", "T", 2, 29, 1610612747},
+		{"intake.gm_player", "game_id, player_id", "P", 3, 32, 100001},
+	}
+	for _, tt := range tests {
+		r := GameLogReqNew("00", "2024-25", "Regular Season", tt.plTm,
+			"01/15/2025", "01/15/2025")
+		if err := GameLogETL(context.Background(), cnf, r,
+			tt.tbl, tt.primKey); err != nil {
+			t.Fatalf("GameLogETL %s: %v", tt.tbl, err)
+		}
+
+		exs := fdb.execs("insert into " + tt.tbl + " (")
+		if len(exs) != 1 {
+			t.Fatalf("%d inserts into %s, want 1", len(exs), tt.tbl)
+		}
+		ex := exs[0]
+		if !strings.HasSuffix(ex.Qry,
+			fmt.Sprintf("on conflict (%s) do nothing", tt.primKey)) {
+			t.Errorf("%s insert doesn't skip existing rows: %s", tt.tbl, ex.Qry)
+		}
+		if len(ex.Args) != tt.rows*tt.cols {
+			t.Errorf("%s insert has %d values, want %d rows x %d columns",
+				tt.tbl, len(ex.Args), tt.rows, tt.cols)
+		}
+		if fmt.Sprint(ex.Args[0]) != "22024" {
+			t.Errorf("%s first season_id = %v, want 22024", tt.tbl, ex.Args[0])
+		}
+		// inserted from the decoded GameLogRows, not the json values
+		if ex.Args[1] != tt.id {
+			t.Errorf("%s first id = %#v, want int64 %d", tt.tbl, ex.Args[1], tt.id)
+		}
+	}
+	if cnf.RowCnt != 5 {
+		t.Errorf("RowCnt = %d, want 5", cnf.RowCnt)
+	}
+	if len(cnf.Warns) > 0 {
+		t.Errorf("unexpected warnings (schema drift?): %v", cnf.Warns)
+	}
+}
+
+// a request with no saved response fails instead of loading nothing
+func TestGameLogETLOfflineMissingFixture(t *testing.T) {
+	_, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+
+	r := GameLogReqNew("00", "2024-25", "Regular Season", "T",
+		"01/20/2025", "01/20/2025")
+	err := GameLogETL(context.Background(), cnf, r,
+		"intake.gm_team", "game_id, team_id")
+	if err == nil || !strings.Contains(err.Error(), "no saved response") {
+		t.Fatalf("GameLogETL err = %v, want no saved response", err)
+	}
+}
+
+func leagueByCode(t *testing.T, code string) League {
+	t.Helper()
+	lg, err := LeagueByCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lg
+}
+
+// a backfill of one game day, regular season only
+func TestGLogRangeETLOffline(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+	cnf.Clock = fixtureClock
+	cnf.STypes = []SznType{SZN_TYPES[1]}
+
+	day := cnf.NightlyDay()
+	err := GLogRangeETL(context.Background(), cnf,
+		[]League{leagueByCode(t, "nba")}, day, day)
+	if err != nil {
+		t.Fatalf("GLogRangeETL: %v", err)
+	}
+
+	for tbl, vals := range map[string]int{
+		"intake.gm_team":   2 * 29,
+		"intake.gm_player": 3 * 32,
+	} {
+		exs := fdb.execs("insert into " + tbl + " (")
+		if len(exs) != 1 || len(exs[0].Args) != vals {
+			t.Errorf("inserts into %s = %v, want 1 insert of %d values",
+				tbl, exs, vals)
+		}
+	}
+	if cnf.RowCnt != 5 {
+		t.Errorf("RowCnt = %d, want 5", cnf.RowCnt)
+	}
+	if len(cnf.Warns) > 0 {
+		t.Errorf("unexpected warnings (schema drift?): %v", cnf.Warns)
+	}
+}
+
+// a range with no fixture fails instead of loading nothing
+func TestGLogRangeETLOfflineMissingFixture(t *testing.T) {
+	_, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+	cnf.STypes = []SznType{SZN_TYPES[1]}
+
+	day := time.Date(2025, 1, 20, 0, 0, 0, 0, ET)
+	err := GLogRangeETL(context.Background(), cnf,
+		[]League{leagueByCode(t, "nba")}, day, day)
+	if err == nil || !strings.Contains(err.Error(), "no saved response") {
+		t.Fatalf("GLogRangeETL err = %v, want no saved response", err)
+	}
+}
+
+func TestSznPlayersETLOffline(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+
+	if err := SznPlayersETL(context.Background(), cnf, "1", "2024-25"); err != nil {
+		t.Fatalf("SznPlayersETL: %v", err)
+	}
+
+	tests := []struct {
+		tbl  string
+		rows int
+		cols int
+	}{
+		{"intake.player", 5, 16},
+		{"intake.wplayer", 2, 17},
+	}
+	for _, tt := range tests {
+		exs := fdb.execs("insert into " + tt.tbl + " (")
+		if len(exs) != 1 {
+			t.Fatalf("%d inserts into %s, want 1", len(exs), tt.tbl)
+		}
+		if !strings.HasSuffix(exs[0].Qry, "on conflict (person_id) do nothing") {
+			t.Errorf("%s insert doesn't skip existing rows: %s",
+				tt.tbl, exs[0].Qry)
+		}
+		if len(exs[0].Args) != tt.rows*tt.cols {
+			t.Errorf("%s insert has %d values, want %d rows x %d columns",
+				tt.tbl, len(exs[0].Args), tt.rows, tt.cols)
+		}
+	}
+	if cnf.RowCnt != 7 {
+		t.Errorf("RowCnt = %d, want 7", cnf.RowCnt)
+	}
+	if len(cnf.Warns) > 0 {
+		t.Errorf("unexpected warnings (schema drift?): %v", cnf.Warns)
+	}
+}
+
+// g league players load into intake.gplayer with the nba team ids kept
+func TestLgPlayersETLGLeague(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+
+	err := LgPlayersETL(context.Background(), cnf,
+		leagueByCode(t, "gleague"), "1", "2024-25")
+	if err != nil {
+		t.Fatalf("LgPlayersETL: %v", err)
+	}
+
+	exs := fdb.execs("insert into intake.gplayer (")
+	if len(exs) != 1 || len(exs[0].Args) != 3*17 {
+		t.Fatalf("inserts into intake.gplayer = %v, want 1 insert of 3 rows", exs)
+	}
+	// numbers in the response go into numeric columns, not booleans
+	cols := fdb.Cols["intake.gplayer"]
+	for i, v := range exs[0].Args {
+		col := "intake.gplayer." + cols[i%len(cols)]
+		if _, ok := v.(string); v != nil && !ok && fdb.Types[col] == "boolean" {
+			t.Errorf("%s is boolean, response has %v", col, v)
+		}
+	}
+	if got := fmt.Sprint(exs[0].Args[3], " ", exs[0].Args[15]); got != "1 1610612747" {
+		t.Errorf("rosterstatus & nba_assigned_team_id = %s, want 1 1610612747", got)
+	}
+	if cnf.RowCnt != 3 {
+		t.Errorf("RowCnt = %d, want 3", cnf.RowCnt)
+	}
+	if len(cnf.Warns) > 0 {
+		t.Errorf("unexpected warnings (schema drift?): %v", cnf.Warns)
+	}
+}
+
+func TestCrntPlayersETLOffline(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+	cnf.Clock = fixtureClock
+
+	// nba teams before the run, wnba has none stored (new database)
+	fdb.Rows["from intake.player"] = [][]driver.Value{
+		{int64(100001), int64(1610612747), "LAL", "Ava Stay"},
+		{int64(100002), int64(1610612747), "LAL", "Ben Trade"},
+		{int64(100003), int64(0), "", "Cal Sign"},
+		{int64(100004), int64(1610612748), "MIA", "Dan Cut"},
+		{int64(100005), int64(1610612752), "NYK", "Eli Gone"},
+		{int64(100006), int64(0), "", "Finn Retired"},
+	}
+
+	if err := CrntPlayersETL(context.Background(), cnf); err != nil {
+		t.Fatalf("CrntPlayersETL: %v", err)
+	}
+
+	// both leagues' current players upserted
+	for _, tbl := range []string{"intake.player", "intake.wplayer"} {
+		exs := fdb.execs("insert into " + tbl + " (")
+		if len(exs) != 1 {
+			t.Fatalf("%d inserts into %s, want 1", len(exs), tbl)
+		}
+		if !strings.Contains(exs[0].Qry, "do update set") {
+			t.Errorf("%s insert isn't an upsert: %s", tbl, exs[0].Qry)
+		}
+	}
+
+	want := []string{
+		"2025-01-15 TRADE: Ben Trade (100002) LAL (1610612747) -> GSW (1610612744)",
+		"2025-01-15 SIGNING: Cal Sign (100003)  (0) -> GSW (1610612744)",
+		"2025-01-15 WAIVER: Dan Cut (100004) MIA (1610612748) ->  (0)",
+		"2025-01-15 SIGNING: Gus New (100007)  (0) -> DEN (1610612743)",
+		"2025-01-15 WAIVER: Eli Gone (100005) NYK (1610612752) ->  (0)",
+	}
+	if !slices.Equal(cnf.Events, want) {
+		t.Errorf("transactions:\n%s\nwant:\n%s",
+			strings.Join(cnf.Events, "\n"), strings.Join(want, "\n"))
+	}
+
+	exs := fdb.execs("insert into intake.transaction (")
+	if len(exs) != 1 || len(exs[0].Args) != len(want)*9 {
+		t.Fatalf("transaction inserts = %v, want 1 insert of %d rows",
+			exs, len(want))
+	}
+
+	// waived players' stored teams are cleared, dropped ones included
+	exs = fdb.execs("update intake.player set team_id = 0")
+	if len(exs) != 1 {
+		t.Fatalf("%d team clears, want 1", len(exs))
+	}
+	if got := fmt.Sprint(exs[0].Args); got != "[100004 100005]" {
+		t.Errorf("cleared teams for %s, want [100004 100005]", got)
+	}
+	if len(fdb.execs("update intake.wplayer")) != 0 {
+		t.Error("cleared wnba teams with no stored players")
+	}
+	if len(cnf.Warns) > 0 {
+		t.Errorf("unexpected warnings (schema drift?): %v", cnf.Warns)
+	}
+}
+
+// a response missing most stored players still upserts them but records no moves
+func TestCrntPlayersETLMassDrop(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+	cnf.Clock = fixtureClock
+
+	var stored [][]driver.Value
+	for i := range 12 {
+		stored = append(stored, []driver.Value{
+			int64(900001 + i), int64(1610612747), "LAL", fmt.Sprint("Old ", i)})
+	}
+	fdb.Rows["from intake.player"] = stored
+
+	if err := CrntPlayersETL(context.Background(), cnf); err != nil {
+		t.Fatalf("CrntPlayersETL: %v", err)
+	}
+	if len(fdb.execs("insert into intake.player (")) != 1 {
+		t.Error("current nba players weren't upserted")
+	}
+	if n := len(fdb.execs("insert into intake.transaction")); n != 0 {
+		t.Errorf("%d transaction inserts, want 0", n)
+	}
+	if n := len(fdb.execs("update intake.player")); n != 0 {
+		t.Errorf("%d team clears, want 0", n)
+	}
+	if len(cnf.Events) != 0 {
+		t.Errorf("unexpected transactions: %v", cnf.Events)
+	}
+	if len(cnf.Warns) != 1 ||
+		!strings.Contains(cnf.Warns[0], "skipping nba transactions") {
+		t.Errorf("warnings = %v, want the skipped nba transactions", cnf.Warns)
+	}
+}
+
+// fixtures first, then fallback bodies, every request's endpoint recorded
+type fallbackFetcher struct {
+	F    Fetcher
+	Mem  *MemFetcher
+	mu   sync.Mutex
+	Reqs []string
+}
+
+func (ff *fallbackFetcher) Fetch(ctx context.Context, gr GetReq) ([]byte, error) {
+	ff.mu.Lock()
+	ff.Reqs = append(ff.Reqs, gr.Endpoint)
+	ff.mu.Unlock()
+	if body, err := ff.F.Fetch(ctx, gr); err == nil {
+		return body, nil
+	}
+	return ff.Mem.Fetch(ctx, gr)
+}
+
+// a -date run loads the day's games without touching the live data steps
+func TestRunNightlyETLPinned(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, FIXTURES)
+	cnf.Clock = fixtureClock
+	cnf.Pinned = true
+	cnf.STypes = []SznType{SZN_TYPES[1]}
+
+	// no schedule fixture, the run goes on without it & loads every step
+	ff := &fallbackFetcher{F: cnf.F, Mem: &MemFetcher{Bodies: map[string][]byte{
+		"/stats/shotchartdetail": []byte(`{"resultSets": [
+			{"name": "Shot_Chart_Detail", "headers": ["GAME_ID"], "rowSet": []}]}`),
+	}}}
+	cnf.F = ff
+
+	if err := RunNightlyETL(context.Background(), cnf); err != nil {
+		t.Fatalf("RunNightlyETL: %v", err)
+	}
+
+	for _, live := range []string{"/stats/commonallplayers",
+		"/stats/commonteamroster", "/stats/leaguestandingsv3",
+		"/stats/commonplayerinfo"} {
+		if slices.Contains(ff.Reqs, live) {
+			t.Errorf("pinned run requested %s", live)
+		}
+	}
+	if len(fdb.execs("insert into intake.gm_team (")) != 1 {
+		t.Error("pinned run didn't load the day's game logs")
+	}
+	for _, tbl := range []string{"intake.player", "intake.transaction",
+		"intake.roster", "intake.standings", "intake.player_info"} {
+		if exs := fdb.execs("insert into " + tbl + " ("); len(exs) != 0 {
+			t.Errorf("pinned run inserted into %s", tbl)
+		}
+	}
+}
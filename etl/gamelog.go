@@ -1,30 +1,83 @@
 package etl
 
 import (
+	"context"
 	"fmt"
-	"strconv"
+	"slices"
 	"time"
 
 	"github.com/jdetok/golib/errd"
 )
 
+// row of the leaguegamelog LeagueGameLog result set, team (T) or player (P)
+type GameLogRow struct {
+	SeasonID   string   `col:"SEASON_ID"`
+	PlayerID   *int64   `col:"PLAYER_ID,opt"` // P only
+	PlayerName *string  `col:"PLAYER_NAME,opt"`
+	TeamID     int64    `col:"TEAM_ID"`
+	TeamAbbr   string   `col:"TEAM_ABBREVIATION"`
+	TeamName   string   `col:"TEAM_NAME"`
+	GameID     string   `col:"GAME_ID"`
+	GameDate   string   `col:"GAME_DATE"`
+	Matchup    string   `col:"MATCHUP"`
+	WL         *string  `col:"WL"`
+	Min        *int     `col:"MIN"`
+	FGM        *int     `col:"FGM"`
+	FGA        *int     `col:"FGA"`
+	FGPct      *float64 `col:"FG_PCT"`
+	FG3M       *int     `col:"FG3M"`
+	FG3A       *int     `col:"FG3A"`
+	FG3Pct     *float64 `col:"FG3_PCT"`
+	FTM        *int     `col:"FTM"`
+	FTA        *int     `col:"FTA"`
+	FTPct      *float64 `col:"FT_PCT"`
+	OReb       *int     `col:"OREB"`
+	DReb       *int     `col:"DREB"`
+	Reb        *int     `col:"REB"`
+	Ast        *int     `col:"AST"`
+	Stl        *int     `col:"STL"`
+	Blk        *int     `col:"BLK"`
+	Tov        *int     `col:"TOV"`
+	PF         *int     `col:"PF"`
+	Pts        *int     `col:"PTS"`
+	PlusMinus  *int     `col:"PLUS_MINUS"`
+	FantasyPts *float64 `col:"FANTASY_PTS,opt"` // P only
+	VideoAvail *int     `col:"VIDEO_AVAILABLE"`
+}
+
+// "n rows | n games | first date - last date" for the log
+func glogSummary(gls []GameLogRow) string {
+	if len(gls) == 0 {
+		return "0 game log rows"
+	}
+	games := make(map[string]bool)
+	first, last := gls[0].GameDate, gls[0].GameDate
+	for _, g := range gls {
+		games[g.GameID] = true
+		first = min(first, g.GameDate)
+		last = max(last, g.GameDate)
+	}
+	return fmt.Sprintf("%d game log rows | %d games | %s - %s",
+		len(gls), len(games), first, last)
+}
+
 func GameLogReqNew(league, season, sType, plTm, dateFrom, dateTo string) GetReq {
 	var gr = GetReq{
 		Host:     HOST,
 		Headers:  HDRS,
 		Endpoint: "/stats/leaguegamelog",
-		Params: []Pair{
-			{"LeagueID", league},
-			{"Season", season},
-			{"SeasonType", sType},
-			{"Counter", "0"},
-			{"Sorter", "DATE"},
-			{"Direction", "DESC"},
-			{"PlayerOrTeam", plTm},
-			{"DateFrom", dateFrom},
-			{"DateTo", dateTo},
-		},
 	}
+	gr.SetParams([]Pair{
+		{"LeagueID", league},
+		{"Season", season},
+		{"SeasonType", sType},
+		{"Counter", "0"},
+		{"Sorter", "DATE"},
+		{"Direction", "DESC"},
+		{"PlayerOrTeam", plTm},
+		{"DateFrom", dateFrom},
+		{"DateTo", dateTo},
+	})
 	return gr
 }
 
@@ -33,24 +86,24 @@ func GameLogReq(league, season, plTm, dateFrom, dateTo string) GetReq {
 		Host:     HOST,
 		Headers:  HDRS,
 		Endpoint: "/stats/leaguegamelog",
-		Params: []Pair{
-			{"LeagueID", league},
-			{"Season", season},
-			{"SeasonType", "Regular+Season"},
-			{"Counter", "0"},
-			{"Sorter", "DATE"},
-			{"Direction", "DESC"},
-			{"PlayerOrTeam", plTm},
-			{"DateFrom", dateFrom},
-			{"DateTo", dateTo},
-		},
 	}
+	gr.SetParams([]Pair{
+		{"LeagueID", league},
+		{"Season", season},
+		{"SeasonType", "Regular Season"},
+		{"Counter", "0"},
+		{"Sorter", "DATE"},
+		{"Direction", "DESC"},
+		{"PlayerOrTeam", plTm},
+		{"DateFrom", dateFrom},
+		{"DateTo", dateTo},
+	})
 	return gr
 }
 
 func GLogParams() LgTbls {
 	var lt LgTbls
-	lt.lgs = []string{"00", "10"}
+	lt.lgs = DefaultLeagues()
 	lt.tbls = []Table{
 		{
 			Name:    "intake.gm_team",
@@ -67,83 +120,53 @@ func GLogParams() LgTbls {
 }
 
 // TODO: specific season/league ETL
-func LgSznGlogs(cnf *Conf, lg, szn string) error {
-	e := errd.InitErr()
+func LgSznGlogs(ctx context.Context, cnf *Conf, lg League, szn string) error {
 	lt := GLogParams()
-	var lg_id string
-	switch lg {
-	case "nba":
-		lg_id = lt.lgs[0] // "00"
-	case "wnba":
-		lg_id = lt.lgs[1] // "10"
-	}
-
-	for _, t := range lt.tbls {
-		for _, s := range []string{"Regular+Season", "Playoffs"} {
-			// create request
-			r := GameLogReqNew(lg_id, szn, s, t.PlTm, "", "")
-			cnf.L.WriteLog(fmt.Sprintf(
-				"attempting to fetch %s: LG=%s, SZN=%s %s, PLTM=%s",
-				r.Endpoint, lg, szn, s, t.PlTm))
-
-			// attempt to fetch & insert for current iteration
-			// func returns run of insert
-			err := GameLogETL(cnf, r, t.Name, t.PrimKey)
-			if err != nil {
-				e.Msg = fmt.Sprintf(
-					"error during daily game log ETL. LG=%s, SZN=%s %s, PLTM=%s",
-					lg, szn, s, t.PlTm)
-				cnf.L.WriteLog(e.Msg)
-				return e.BuildErr(err)
-			}
-			// success, next call
-			cnf.L.WriteLog(fmt.Sprintf(
-				"finished with LG=%s, SZN=%s %s, PLTM=%s",
-				lg, szn, s, t.PlTm))
-		}
-	}
-	return nil
+	return GetManyGLogs(ctx, cnf, []League{lg}, lt.tbls, szn)
 }
 
 // run single season
-func GetManyGLogs(cnf *Conf, lgs []string, tbls []Table, szn string) error {
+func GetManyGLogs(
+	ctx context.Context, cnf *Conf, lgs []League, tbls []Table, szn string,
+) error {
 	e := errd.InitErr()
-	for i := range lgs { // outer loop, 2 calls per lg
-		sznY, err := strconv.Atoi(szn[:4])
+	for _, lg := range lgs { // outer loop, 2 calls per lg
+		lSzn, _, started, err := lg.SznOf(szn)
 		if err != nil {
 			e.Msg = fmt.Sprintf(
-				"getting int from season %s", szn)
+				"error parsing season %s", szn)
 			cnf.L.WriteLog(e.Msg)
 			return e.BuildErr(err)
-		} // no wnba pre 1997
-		if lgs[i] == "10" && sznY < 1996 {
+		} // e.g. no wnba pre 1997
+		if !started {
 			cnf.L.WriteLog(fmt.Sprintf(
-				"skipping WNBA %s - first WNBA season was 1997-98", szn))
+				"skipping %s %s - first %s season was %s",
+				lg.Abbr, szn, lg.Abbr, lg.Szn(lg.First)))
 			continue
 		} // loop through tables (PlTm, intake.gm_team, intake.gm_player)
 		for _, t := range tbls {
-			// get player/team reg and playoffs
-			for _, s := range []string{"Regular+Season", "Playoffs"} {
+			// get player/team for each season type (reg, playoffs, etc)
+			for _, s := range LgSznTypes(cnf.sznTypes(), lg.ID, false) {
 				// create request
-				r := GameLogReqNew(lgs[i], szn, s, t.PlTm, "", "")
+				r := GameLogReqNew(lg.ID, lSzn, s, t.PlTm, "", "")
 				cnf.L.WriteLog(fmt.Sprintf(
 					"attempting to fetch %s: LG=%s, SZN=%s %s, PLTM=%s",
-					r.Endpoint, lgs[i], szn, s, t.PlTm))
+					r.Endpoint, lg.Code, lSzn, s, t.PlTm))
 
 				// attempt to fetch & insert for current iteration
 				// func returns run of insert
-				err := GameLogETL(cnf, r, t.Name, t.PrimKey)
+				err := GameLogETL(ctx, cnf, r, t.Name, t.PrimKey)
 				if err != nil {
 					e.Msg = fmt.Sprintf(
-						"error during daily game log ETL. LG=%s, SZN=%s %s, PLTM=%s",
-						lgs[i], szn, s, t.PlTm)
+						"error during game log ETL. LG=%s, SZN=%s %s, PLTM=%s",
+						lg.Code, lSzn, s, t.PlTm)
 					cnf.L.WriteLog(e.Msg)
 					return e.BuildErr(err)
 				}
 				// success, next call
 				cnf.L.WriteLog(fmt.Sprintf(
 					"finished with LG=%s, SZN=%s %s, PLTM=%s",
-					lgs[i], szn, s, t.PlTm))
+					lg.Code, lSzn, s, t.PlTm))
 			}
 		}
 	}
@@ -151,10 +174,10 @@ func GetManyGLogs(cnf *Conf, lgs []string, tbls []Table, szn string) error {
 }
 
 // should be able to use this for the custom mode without league specified
-func GLogSeasonETL(cnf *Conf, szn string) error {
+func GLogSeasonETL(ctx context.Context, cnf *Conf, szn string) error {
 	e := errd.InitErr()
 	lt := GLogParams()
-	err := GetManyGLogs(cnf, lt.lgs, lt.tbls, szn)
+	err := GetManyGLogs(ctx, cnf, lt.lgs, lt.tbls, szn)
 	if err != nil {
 		e.Msg = fmt.Sprintf("error running ETL for %s", szn)
 		cnf.L.WriteLog(e.Msg)
@@ -168,78 +191,103 @@ func GLogSeasonETL(cnf *Conf, szn string) error {
 nightly game log fetch both PlayerTeam=P & T and NBA and WNBA
 using yeseterday's date as DateFrom/DateTo
 */
-func GLogDailyETL(cnf *Conf) error {
+func GLogDailyETL(ctx context.Context, cnf *Conf) error {
+	day := cnf.NightlyDay()
+	return GLogRangeETL(ctx, cnf, GLogParams().lgs, day, day)
+}
+
+/*
+game logs for every league in lgs between from & to (game days, inclusive) as
+DateFrom/DateTo, one set of calls per season the range covers in each league
+e.g. 2025-04-25 - 2025-05-25 calls nba 2024-25 & wnba 2025 from 05/01
+*/
+func GLogRangeETL(
+	ctx context.Context, cnf *Conf, lgs []League, from, to time.Time,
+) error {
 	e := errd.InitErr()
-	yesterday := Yesterday(time.Now())
-	lt := GLogParams()
-	sl := GetSeasons()
-	var szns = []string{sl.Szn, sl.WSzn}
+	tbls := GLogParams().tbls
 
-	// makes 4 calls to leaguegamelog endpoint
-	for i := range lt.lgs { // outer loop, 2 calls per lg
-		for _, t := range lt.tbls {
-			for _, s := range []string{"Regular+Season", "Playoffs"} {
-				// create request
-				r := GameLogReqNew(
-					lt.lgs[i], szns[i], s, t.PlTm, yesterday, yesterday)
-				cnf.L.WriteLog(fmt.Sprintf(
-					"attempting to fetch %s: LG=%s, SZN=%s %s, PLTM=%s, DATE=%s",
-					r.Endpoint, lt.lgs[i], szns[i], s, t.PlTm, yesterday))
-				// run etl
-				err := GameLogETL(cnf, r, t.Name, t.PrimKey)
-				if err != nil {
-					e.Msg = fmt.Sprintf(
-						"error during daily game log ETL. LG=%s, SZN=%s, PLTM=%s, DATE=%s",
-						lt.lgs[i], szns[i], t.PlTm, yesterday)
-					cnf.L.WriteLog(e.Msg)
-					return e.BuildErr(err)
+	// player & team calls to leaguegamelog per league, season & season type
+	for _, lg := range lgs {
+		sps := lg.SznSpans(from, to)
+		if len(sps) == 0 {
+			cnf.L.WriteLog(fmt.Sprintf(
+				"skipping %s game logs %s - %s - no season in progress",
+				lg.Abbr, from.In(ET).Format("01/02/2006"),
+				to.In(ET).Format("01/02/2006")))
+		}
+		for _, sp := range sps {
+			szn := lg.Szn(sp.Szn)
+			dFrom := sp.From.Format("01/02/2006")
+			dTo := sp.To.Format("01/02/2006")
+			for _, t := range tbls {
+				for _, s := range LgSznTypes(cnf.sznTypes(), lg.ID, false) {
+					// create request
+					r := GameLogReqNew(lg.ID, szn, s, t.PlTm, dFrom, dTo)
+					cnf.L.WriteLog(fmt.Sprintf(
+						"attempting to fetch %s: LG=%s, SZN=%s %s, PLTM=%s, DATES=%s - %s",
+						r.Endpoint, lg.Code, szn, s, t.PlTm, dFrom, dTo))
+					// run etl
+					err := GameLogETL(ctx, cnf, r, t.Name, t.PrimKey)
+					if err != nil {
+						e.Msg = fmt.Sprintf(
+							"error during game log ETL. LG=%s, SZN=%s, PLTM=%s, DATES=%s - %s",
+							lg.Code, szn, t.PlTm, dFrom, dTo)
+						cnf.L.WriteLog(e.Msg)
+						return e.BuildErr(err)
+					}
 				}
-				// create request
-				// r := GameLogReq(lt.lgs[i], szns[i], t.PlTm, yesterday, yesterday)
-				// cnf.L.WriteLog(fmt.Sprintf(
-				// 	"attempting to fetch %s: LG=%s, SZN=%s, PLTM=%s, DATE=%s",
-				// 	r.Endpoint, lt.lgs[i], szns[i], t.PlTm, yesterday))
-
-				// attempt to fetch & insert for current iteration
-
+				// success, next call
+				cnf.L.WriteLog(fmt.Sprintf(
+					"finished with LG=%s, SZN=%s, PLTM=%s, DATES=%s - %s",
+					lg.Code, szn, t.PlTm, dFrom, dTo))
 			}
-			// success, next call
-			cnf.L.WriteLog(fmt.Sprintf(
-				"finished with LG=%s, SZN=%s, PLTM=%s, DATE=%s",
-				lt.lgs[i], szns[i], t.PlTm, yesterday))
 		}
 	}
 	return nil
 }
 
-func GameLogETL(cnf *Conf, r GetReq, tbl, primKey string) error {
+func GameLogETL(
+	ctx context.Context, cnf *Conf, r GetReq, tbl, primKey string,
+) error {
 	e := errd.InitErr()
 
 	// call endpoint in HTTP request, return Resp struct
-	resp, err := RequestResp(cnf.L, r)
+	resp, err := RequestResp(ctx, cnf, r)
 	if err != nil {
 		e.Msg = fmt.Sprintf("error getting response for %s", r.Endpoint)
 		cnf.L.WriteLog(e.Msg)
 		return e.BuildErr(err)
 	}
 
-	// get cols/rows from resp, return early when no rows in response
-	var cols []string = resp.ResultSets[0].Headers
-	var rows [][]any = resp.ResultSets[0].RowSet
-	if len(rows) == 0 {
-		cnf.L.WriteLog("response returned 0 rows, exiting")
-		return nil
+	st := SetTbl{Set: "LeagueGameLog", Tbl: tbl, PrimKey: primKey}
+	rs, err := resp.Set(st.Set)
+	if err != nil {
+		e.Msg = fmt.Sprintf("unexpected response for %s: %v", r.Endpoint, err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// decode by header name to make sure the columns are what we expect
+	gls, err := DecodeRows[GameLogRow](rs)
+	if err != nil && !OnlyExtra(err) {
+		e.Msg = fmt.Sprintf("unexpected game log columns: %v", err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	} else if err != nil { // new api columns, left out of the insert
+		cnf.L.WriteLog(fmt.Sprintf("WARNING: %v", err))
+		if !slices.Contains(cnf.Warns, err.Error()) {
+			cnf.Warns = append(cnf.Warns, err.Error())
+		}
+	}
+	cnf.L.WriteLog(glogSummary(gls))
+
+	// insert the typed rows, not the response's positional ones
+	grs, err := EncodeRows(rs.Name, FieldCols[GameLogRow](rs.Headers), gls)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error encoding game log rows: %v", err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
 	}
-	cnf.L.WriteLog(
-		fmt.Sprintf("response returned %d fields & %d rows",
-			len(cols), len(rows)))
-
-	// prepare the sql statement & chunks of values
-	ins := MakeInsert(
-		tbl,
-		primKey,
-		cols,
-		rows,
-	) // attempt to insert rows from response
-	return ins.InsertFast(cnf)
+	return LoadSet(ctx, cnf, grs, st)
 }
@@ -0,0 +1,118 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+filters for game ids already loaded in intake.gm_team, empty fields are ignored
+  - Lg: League.ID, e.g. "00" (first 2 digits of the 10 digit game id)
+  - Szn: start year of the season, e.g. "2024" for 2024-25 NBA or 2024 WNBA
+  - Date: game date as MM/DD/YYYY
+*/
+type GameFilter struct {
+	Lg   string
+	Szn  string
+	Date string
+}
+
+/*
+game ids in intake.gm_team matching f that have no row yet in tbl
+returned as the 10 digit strings the api expects, e.g. 0022400001
+*/
+func NewGameIDs(
+	ctx context.Context, cnf *Conf, tbl string, f GameFilter,
+) ([]string, error) {
+	var where []string
+	var args []any
+	add := func(cond, val string) {
+		args = append(args, val)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Lg != "" {
+		add("left(lpad(cast(a.game_id as varchar(10)), 10, '0'), 2) = $%d", f.Lg)
+	}
+	if f.Szn != "" {
+		add("right(cast(a.season_id as varchar(10)), 4) = $%d", f.Szn)
+	}
+	if f.Date != "" {
+		add("a.game_date = to_date($%d, 'MM/DD/YYYY')", f.Date)
+	}
+	qry := fmt.Sprintf(`
+		select distinct a.game_id from intake.gm_team a
+		where not exists (select 1 from %s b where b.game_id = a.game_id)`, tbl)
+	if len(where) > 0 {
+		qry += " and " + strings.Join(where, " and ")
+	}
+	qry += " order by a.game_id"
+
+	rows, err := cnf.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gids []string
+	for rows.Next() {
+		var gid int64
+		if err := rows.Scan(&gid); err != nil {
+			return nil, err
+		}
+		gids = append(gids, fmt.Sprintf("%010d", gid))
+	}
+	return gids, rows.Err()
+}
+
+/*
+run fn for each game id, a failed game is logged & added to cnf.Errs without
+stopping the rest, stops early when ctx is cancelled or the breaker is open
+what describes the work for the log, e.g. "box score summary"
+*/
+func PerGameETL(
+	ctx context.Context, cnf *Conf, what string, gids []string,
+	fn func(gid string) error,
+) error {
+	return PerIDETL(ctx, cnf, what, "game", gids, fn)
+}
+
+// PerGameETL for any kind of id, unit names it in the log, e.g. "player"
+func PerIDETL(
+	ctx context.Context, cnf *Conf, what, unit string, ids []string,
+	fn func(id string) error,
+) error {
+	e := errd.InitErr()
+	stT := time.Now()
+	var fails int
+	cnf.L.WriteLog(fmt.Sprintf("attempting %s ETL for %d %ss",
+		what, len(ids), unit))
+	for i, id := range ids {
+		if err := fn(id); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if cnf.Brk.Open() {
+				e.Msg = fmt.Sprintf(
+					"circuit breaker open, stopping %s ETL at %s %d/%d",
+					what, unit, i+1, len(ids))
+				cnf.L.WriteLog(e.Msg)
+				return e.BuildErr(ErrBreakerOpen)
+			}
+			fails++
+			e.Msg = fmt.Sprintf("error with %s ETL for %s %s", what, unit, id)
+			cnf.L.WriteLog(e.Msg)
+			cnf.Errs = append(cnf.Errs, e.Msg)
+			continue
+		}
+		cnf.L.WriteLog(fmt.Sprintf("%s %d/%d complete: %s %s",
+			what, i+1, len(ids), unit, id))
+	}
+	cnf.L.WriteLog(fmt.Sprintf(
+		"====  finished %s ETL for %d %ss (%d failed) after %v",
+		what, len(ids), unit, fails, time.Since(stT)))
+	return nil
+}
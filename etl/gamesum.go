@@ -0,0 +1,103 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jdetok/golib/errd"
+)
+
+// calls boxscoresummaryv2 for line scores, attendance, officials, inactives
+
+func GameSumReq(gameID string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/boxscoresummaryv2",
+	}
+	gr.SetParams([]Pair{
+		{"GameID", gameID},
+	})
+	return gr
+}
+
+/*
+result sets of a box score summary & their intake tables
+Officials, InactivePlayers, GameInfo & OtherStats don't include the game id,
+it's added to each row
+GameSummary is loaded last so a game that fails part way is picked up again
+*/
+func GameSumSets(gameID string) []SetTbl {
+	var gid = []Pair{{"GAME_ID", gameID}}
+	return []SetTbl{
+		{
+			Set:     "LineScore",
+			Tbl:     "intake.gm_linescore",
+			PrimKey: "game_id, team_id",
+		},
+		{
+			Set:     "GameInfo",
+			Tbl:     "intake.gm_info",
+			PrimKey: "game_id",
+			Consts:  gid,
+		},
+		{
+			Set:     "OtherStats",
+			Tbl:     "intake.gm_other",
+			PrimKey: "game_id, team_id",
+			Consts:  gid,
+			Opt:     true, // not returned for older games
+		},
+		{
+			Set:     "Officials",
+			Tbl:     "intake.gm_official",
+			PrimKey: "game_id, official_id",
+			Consts:  gid,
+			Opt:     true,
+		},
+		{
+			Set:     "InactivePlayers",
+			Tbl:     "intake.gm_inactive",
+			PrimKey: "game_id, player_id",
+			Consts:  gid,
+			Opt:     true,
+		},
+		{ // last: NewGameIDs treats a gm_summary row as done
+			Set:     "GameSummary",
+			Tbl:     "intake.gm_summary",
+			PrimKey: "game_id",
+		},
+	}
+}
+
+// fetch the box score summary for one game & load each result set
+func GameSumETL(ctx context.Context, cnf *Conf, gameID string) error {
+	e := errd.InitErr()
+	r := GameSumReq(gameID)
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: game %s",
+			r.Endpoint, gameID)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp, GameSumSets(gameID))
+}
+
+/*
+box score summaries for games in intake.gm_team matching f that aren't in
+intake.gm_summary yet, run after the game logs are loaded
+*/
+func GameSumsETL(ctx context.Context, cnf *Conf, f GameFilter) error {
+	e := errd.InitErr()
+	gids, err := NewGameIDs(ctx, cnf, "intake.gm_summary", f)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting new game ids for %+v", f)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return PerGameETL(ctx, cnf, "box score summary", gids,
+		func(gid string) error {
+			return GameSumETL(ctx, cnf, gid)
+		})
+}
@@ -1,7 +1,10 @@
 package etl
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +18,7 @@ type InsertStmnt struct {
 	Vals    []any
 	Rows    [][]any
 	Chunks  [][][]any
+	Upsert  []string // columns updated on conflict, nil does nothing
 }
 
 type Table struct {
@@ -24,7 +28,7 @@ type Table struct {
 }
 
 type LgTbls struct {
-	lgs  []string
+	lgs  []League
 	tbls []Table
 }
 
@@ -90,8 +94,13 @@ func (ins *InsertStmnt) ChunkVals() {
 	}
 }
 
-// loop through the chunks & attempt to insert all rows from each one
-func (ins *InsertStmnt) InsertFast(cnf *Conf) error {
+/*
+loop through the chunks & attempt to insert all rows from each one
+each chunk is a single statement, so it's either inserted in full or not at all
+once ctx is cancelled no new chunks start & chunks still running are
+cancelled, postgres rolls those statements back
+*/
+func (ins *InsertStmnt) InsertFast(ctx context.Context, cnf *Conf) error {
 	e := errd.InitErr()
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -101,13 +110,22 @@ func (ins *InsertStmnt) InsertFast(cnf *Conf) error {
 		wg.Add(1)
 		go func(i int, c [][]any) {
 			defer wg.Done()
+			if ctx.Err() != nil { // cancelled before this chunk started
+				errCh <- ctx.Err()
+				return
+			}
 			st := time.Now()
 			cnf.L.WriteLog(
 				fmt.Sprintf(
 					"starting chunk %d/%d - %v", i+1, len(ins.Chunks), st))
-			res, err := cnf.DB.Exec(ins.BuildStmnt(c), ValsFromSet(c)...)
+			res, err := cnf.DB.ExecContext(ctx, ins.BuildStmnt(c), ValsFromSet(c)...)
 			if err != nil {
 				e.Msg = fmt.Sprintf("error inserting chunk %d/%d", i+1, len(ins.Chunks))
+				if ctx.Err() != nil {
+					e.Msg = fmt.Sprintf("chunk %d/%d cancelled & rolled back",
+						i+1, len(ins.Chunks))
+					cnf.L.WriteLog(e.Msg)
+				}
 				errCh <- e.BuildErr(err)
 				return
 			}
@@ -125,8 +143,6 @@ func (ins *InsertStmnt) InsertFast(cnf *Conf) error {
 				),
 			)
 			mu.Unlock()
-			time.Sleep(1 * time.Second)
-
 		}(i, c)
 
 	}
@@ -135,6 +151,12 @@ func (ins *InsertStmnt) InsertFast(cnf *Conf) error {
 	close(errCh)
 	if len(errCh) > 0 {
 		err := <-errCh
+		if ctx.Err() != nil {
+			cnf.L.WriteLog(fmt.Sprintf(
+				"insert into %s cancelled: %d/%d chunks failed or not started",
+				ins.Tbl, len(errCh)+1, len(ins.Chunks)))
+			return ctx.Err()
+		}
 		e.Msg = "one or more chunks failed to insert"
 		return e.BuildErr(err)
 	}
@@ -143,10 +165,10 @@ func (ins *InsertStmnt) InsertFast(cnf *Conf) error {
 }
 
 // loop through the chunks & attempt to insert all rows from each one
-func (ins *InsertStmnt) Insert(cnf *Conf) error {
+func (ins *InsertStmnt) Insert(ctx context.Context, cnf *Conf) error {
 	e := errd.InitErr()
 	for i, c := range ins.Chunks {
-		res, err := cnf.DB.Exec(ins.BuildStmnt(c), ValsFromSet(c)...)
+		res, err := cnf.DB.ExecContext(ctx, ins.BuildStmnt(c), ValsFromSet(c)...)
 		if err != nil {
 			e.Msg = fmt.Sprintf("error inserting chunk %d/%d", i+1, len(ins.Chunks))
 			return e.BuildErr(err)
@@ -164,12 +186,41 @@ func (ins *InsertStmnt) Insert(cnf *Conf) error {
 	return nil
 }
 
-// construct the SQL statement to execute
+/*
+construct the SQL statement to execute
+existing rows are left alone unless ins.Upsert has columns, then those are set
+to the new row's values
+*/
 func (ins *InsertStmnt) BuildStmnt(chunk [][]any) string {
 	stmnt := fmt.Sprintf("insert into %s (", ins.Tbl)
 	ins.addCols(&stmnt)
 	ins.addChunkParams(&stmnt, chunk)
-	return fmt.Sprintf("%s on conflict (%s) do nothing", stmnt, ins.PrimKey)
+	if len(ins.Upsert) == 0 {
+		return fmt.Sprintf("%s on conflict (%s) do nothing", stmnt, ins.PrimKey)
+	}
+	stmnt += fmt.Sprintf(" on conflict (%s) do update set ", ins.PrimKey)
+	for i, c := range ins.Upsert {
+		stmnt += fmt.Sprintf("%s = excluded.%s", c, c)
+		if i < (len(ins.Upsert) - 1) {
+			stmnt += ", "
+		}
+	}
+	return stmnt
+}
+
+// every column in cols that isn't part of primKey, for ins.Upsert
+func UpdateCols(primKey string, cols []string) []string {
+	var keys []string
+	for k := range strings.SplitSeq(primKey, ",") {
+		keys = append(keys, strings.ToLower(strings.TrimSpace(k)))
+	}
+	var upd []string
+	for _, c := range cols {
+		if !slices.Contains(keys, strings.ToLower(c)) {
+			upd = append(upd, c)
+		}
+	}
+	return upd
 }
 
 // use ins.Cols to add list of columns to sql statement
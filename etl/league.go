@@ -0,0 +1,144 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+leagues the etl can fetch, adding a league is an entry here plus its intake
+player table, lg.league is kept in sync by SyncLeagues
+only Dflt leagues are fetched when -lg isn't passed
+*/
+type League struct {
+	ID      string     // LeagueID param & first 2 digits of game ids, e.g. "00"
+	LgID    int        // lg.league.lg_id
+	Code    string     // -lg flag value & lg.league.lg_cde, e.g. "nba"
+	Abbr    string     // lg.league.lg, e.g. "NBA"
+	Name    string     // lg.league.lg_name
+	First   Season     // the league's first season
+	StartMo time.Month // month a season starts, see SznOn
+	EndMo   time.Month // last month of the playoffs
+	SznFmt  string     // SZN_SPAN (2024-25) or SZN_YEAR (2024)
+	PlrTbl  string     // intake table for commonallplayers
+	Dflt    bool       // fetched when no league is passed
+}
+
+const (
+	SZN_SPAN = "span" // season crosses a new year, 2024-25
+	SZN_YEAR = "year" // season within a calendar year, 2024
+)
+
+var LEAGUES = []League{
+	{
+		ID: "00", LgID: 0, Code: "nba", Abbr: "NBA",
+		Name:  "National Basketball Association",
+		First: 1946, StartMo: time.October, EndMo: time.June,
+		SznFmt: SZN_SPAN, PlrTbl: "intake.player", Dflt: true,
+	},
+	{
+		ID: "10", LgID: 1, Code: "wnba", Abbr: "WNBA",
+		Name:  "Women's National Basketball Association",
+		First: 1997, StartMo: time.May, EndMo: time.October,
+		SznFmt: SZN_YEAR, PlrTbl: "intake.wplayer", Dflt: true,
+	},
+	{
+		ID: "20", LgID: 2, Code: "gleague", Abbr: "GL",
+		Name:  "NBA G League",
+		First: 2001, StartMo: time.November, EndMo: time.April,
+		SznFmt: SZN_SPAN, PlrTbl: "intake.gplayer",
+	},
+}
+
+// leagues fetched when no league is passed, nba & wnba
+func DefaultLeagues() []League {
+	var lgs []League
+	for _, lg := range LEAGUES {
+		if lg.Dflt {
+			lgs = append(lgs, lg)
+		}
+	}
+	return lgs
+}
+
+// league for a -lg flag value, e.g. "nba"
+func LeagueByCode(code string) (League, error) {
+	for _, lg := range LEAGUES {
+		if strings.EqualFold(lg.Code, code) {
+			return lg, nil
+		}
+	}
+	var codes []string
+	for _, lg := range LEAGUES {
+		codes = append(codes, lg.Code)
+	}
+	return League{}, fmt.Errorf("invalid league '%s': must be one of %s",
+		code, strings.Join(codes, ", "))
+}
+
+// league for an api league id, e.g. "10"
+func LeagueByID(id string) (League, bool) {
+	for _, lg := range LEAGUES {
+		if lg.ID == id {
+			return lg, true
+		}
+	}
+	return League{}, false
+}
+
+// league for an lg.league.lg_id, e.g. 1
+func LeagueByLgID(lgID int) (League, bool) {
+	for _, lg := range LEAGUES {
+		if lg.LgID == lgID {
+			return lg, true
+		}
+	}
+	return League{}, false
+}
+
+// season string in the league's format, 2024-25 or 2024
+func (lg League) Szn(s Season) string {
+	return s.Fmt(lg.SznFmt)
+}
+
+/*
+szn (e.g. 2024-25, 2024 or 22024) in the league's format & as a Season
+false if the league hadn't started yet
+*/
+func (lg League) SznOf(szn string) (string, Season, bool, error) {
+	s, err := ParseSeason(szn)
+	if err != nil {
+		return "", 0, false, err
+	}
+	return lg.Szn(s), s, s >= lg.First, nil
+}
+
+// "0, 1" list of lg.league ids for sql in () filters
+func lgIDList(lgs []League) string {
+	var ids []string
+	for _, lg := range lgs {
+		ids = append(ids, strconv.Itoa(lg.LgID))
+	}
+	return strings.Join(ids, ", ")
+}
+
+// upsert every registry league into lg.league
+func SyncLeagues(ctx context.Context, cnf *Conf) error {
+	rs := ResultSet{
+		Name:    "Leagues",
+		Headers: []string{"LG_ID", "LG_CDE", "LG", "LG_NAME"},
+	}
+	for _, lg := range LEAGUES {
+		rs.RowSet = append(rs.RowSet,
+			[]any{lg.LgID, lg.Code, lg.Abbr, lg.Name})
+	}
+	return LoadSet(ctx, cnf, rs, SetTbl{
+		Set:     rs.Name,
+		Tbl:     "lg.league",
+		PrimKey: "lg_id",
+		Upsert:  true,
+	})
+}
@@ -0,0 +1,92 @@
+package etl
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+token bucket shared by every api request in a run
+holds up to Burst tokens, refilled at RPS tokens per second, each request takes
+one token & waits when the bucket is empty, so pacing holds no matter how many
+loops or goroutines are sending requests
+a nil *Limiter doesn't limit
+*/
+type Limiter struct {
+	RPS    float64
+	Burst  int
+	Clock  Clock // refill time, nil uses the system clock
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewLimiter(rps float64, burst int) *Limiter {
+	burst = max(burst, 1)
+	return &Limiter{
+		RPS:    rps,
+		Burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+/*
+block until a token is available, rps <= 0 means unlimited
+returns ctx.Err() if ctx is cancelled while waiting, the token it had reserved
+goes back in the bucket
+*/
+func (lim *Limiter) Wait(ctx context.Context) error {
+	if lim == nil || lim.RPS <= 0 {
+		return ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	wait := lim.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		lim.release()
+		return ctx.Err()
+	}
+}
+
+/*
+refill for the time since the last call & take a token, returns how long the
+caller has to wait for it
+going negative reserves a spot in line for callers that arrive while the
+bucket is empty
+*/
+func (lim *Limiter) reserve() time.Duration {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	now := time.Now()
+	if lim.Clock != nil {
+		now = lim.Clock.Now()
+	}
+	lim.tokens = min(
+		lim.tokens+now.Sub(lim.last).Seconds()*lim.RPS, float64(lim.Burst))
+	lim.last = now
+
+	lim.tokens--
+	if lim.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-lim.tokens / lim.RPS * float64(time.Second))
+}
+
+// give back a reserved token, the caller stopped waiting for it
+func (lim *Limiter) release() {
+	lim.mu.Lock()
+	lim.tokens = min(lim.tokens+1, float64(lim.Burst))
+	lim.mu.Unlock()
+}
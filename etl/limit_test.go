@@ -0,0 +1,87 @@
+package etl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// limiter with a full bucket at t0 whose refill time is set by the test
+func testLimiter(rps float64, burst int, t0 time.Time) *Limiter {
+	lim := NewLimiter(rps, burst)
+	lim.Clock = FixedClock{t0}
+	lim.last = t0
+	return lim
+}
+
+func TestLimiterRateBurst(t *testing.T) {
+	t0 := time.Date(2025, 1, 16, 6, 0, 0, 0, ET)
+	lim := testLimiter(2, 3, t0)
+	ms := time.Millisecond
+
+	tests := []struct {
+		name  string
+		after time.Duration // clock time since t0
+		waits []time.Duration
+	}{
+		// a full bucket lets 3 through, then one every 500ms
+		{"burst then rate", 0, []time.Duration{0, 0, 0, 500 * ms, 1000 * ms}},
+		// 1s refills the 2 reserved tokens, the next waits its 500ms
+		{"refill pays back reservations", time.Second, []time.Duration{500 * ms}},
+		// an idle minute refills to the burst, not 120 tokens
+		{"refill capped at burst", 61 * time.Second,
+			[]time.Duration{0, 0, 0, 500 * ms}},
+	}
+	for _, tt := range tests {
+		lim.Clock = FixedClock{t0.Add(tt.after)}
+		for i, want := range tt.waits {
+			if got := lim.reserve(); got != want {
+				t.Errorf("%s: call %d waits %v, want %v", tt.name, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	t0 := time.Date(2025, 1, 16, 6, 0, 0, 0, ET)
+	lim := testLimiter(1, 2, t0)
+
+	// burst tokens don't wait
+	for i := range 2 {
+		if err := lim.Wait(context.Background()); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+
+	// cancelled while waiting 1s for the next token
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := lim.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := lim.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait after cancel err = %v, want %v",
+			err, context.DeadlineExceeded)
+	}
+
+	// both cancelled calls gave their spot back, the next waits 1s not 3s
+	if got := lim.reserve(); got != time.Second {
+		t.Errorf("wait after cancelled calls = %v, want 1s", got)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	var nilLim *Limiter
+	for _, lim := range []*Limiter{nilLim, NewLimiter(0, 1)} {
+		for range 100 {
+			if err := lim.Wait(context.Background()); err != nil {
+				t.Fatalf("unlimited Wait: %v", err)
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := nilLim.Wait(ctx); err != context.Canceled {
+		t.Errorf("nil limiter Wait err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,116 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+maps a named result set to the intake table it loads, pass a slice of these
+to LoadSets to fill several tables from one response, e.g.
+
+	[]SetTbl{
+		{Set: "LineScore", Tbl: "intake.gm_linescore", PrimKey: "game_id, team_id"},
+		{Set: "Officials", Tbl: "intake.gm_official", PrimKey: "game_id, official_id"},
+	}
+*/
+type SetTbl struct {
+	Set     string      // result set name in the response
+	Tbl     string      // target table
+	PrimKey string      // conflict target, define like "key" or "key1, key2"
+	Drift   DriftPolicy // overrides cnf.Drift for this table if set
+	Opt     bool        // skip instead of error when the set isn't returned
+	Consts  []Pair      // columns added to every row if the set lacks them
+	Upsert  bool        // update existing rows' non key columns, not skip
+}
+
+// load every result set in sets from resp into its table
+func LoadSets(ctx context.Context, cnf *Conf, resp Resp, sets []SetTbl) error {
+	e := errd.InitErr()
+	for _, st := range sets {
+		rs, err := resp.Set(st.Set)
+		if err != nil && st.Opt {
+			cnf.L.WriteLog(fmt.Sprintf(
+				"%s not in response, skipping %s", st.Set, st.Tbl))
+			continue
+		}
+		if err != nil {
+			e.Msg = fmt.Sprintf("unexpected response: %v", err)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		if err := LoadSet(ctx, cnf, rs, st); err != nil {
+			e.Msg = fmt.Sprintf("error loading %s into %s", st.Set, st.Tbl)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+	return nil
+}
+
+/*
+insert a single result set into st.Tbl
+st.Consts are added first, empty sets are skipped, columns are checked for
+drift before the insert
+*/
+func LoadSet(ctx context.Context, cnf *Conf, rs ResultSet, st SetTbl) error {
+	e := errd.InitErr()
+	for _, c := range st.Consts {
+		rs = rs.WithCol(c.Key, c.Val)
+	}
+	var cols []string = rs.Headers
+	var rows [][]any = rs.RowSet
+	if len(rows) == 0 {
+		cnf.L.WriteLog(fmt.Sprintf("%s returned 0 rows, nothing to insert into %s",
+			rs.Name, st.Tbl))
+		return nil
+	}
+	cnf.L.WriteLog(
+		fmt.Sprintf("%s returned %d fields & %d rows for %s",
+			rs.Name, len(cols), len(rows), st.Tbl))
+
+	// compare headers to the table's columns before inserting
+	cols, rows, err := cnf.checkDrift(ctx, st.Tbl, st.Drift, cols, rows)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error checking %s columns: %v", st.Tbl, err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// prepare the sql statement & chunks of values
+	ins := MakeInsert(
+		st.Tbl,
+		st.PrimKey,
+		cols,
+		rows,
+	) // attempt to insert rows from response
+	if st.Upsert {
+		ins.Upsert = UpdateCols(st.PrimKey, cols)
+	}
+	return ins.InsertFast(ctx, cnf)
+}
+
+/*
+copy of rs with hdr prepended to the headers & val to every row, e.g. the
+game id for sets that don't include it
+rs is returned as is if it already has hdr
+*/
+func (rs ResultSet) WithCol(hdr string, val any) ResultSet {
+	for _, h := range rs.Headers {
+		if strings.EqualFold(h, hdr) {
+			return rs
+		}
+	}
+	out := ResultSet{
+		Name:    rs.Name,
+		Headers: append([]string{hdr}, rs.Headers...),
+		RowSet:  make([][]any, len(rs.RowSet)),
+	}
+	for i, r := range rs.RowSet {
+		out.RowSet[i] = append([]any{val}, r...)
+	}
+	return out
+}
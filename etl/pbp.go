@@ -0,0 +1,175 @@
+package etl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+calls playbyplayv3 for every event in a game
+the response isn't result sets, the actions are decoded into RespPBP then
+turned into a PlayByPlay ResultSet so they load like any other set
+*/
+
+type RespPBP struct {
+	Game PBPGame `json:"game"`
+}
+
+type PBPGame struct {
+	GameID  string      `json:"gameId"`
+	Actions []PBPAction `json:"actions"`
+}
+
+type PBPAction struct {
+	ActionNumber   int    `json:"actionNumber"`
+	Clock          string `json:"clock"`
+	Period         int    `json:"period"`
+	TeamID         int64  `json:"teamId"`
+	TeamTricode    string `json:"teamTricode"`
+	PersonID       int64  `json:"personId"`
+	PlayerName     string `json:"playerName"`
+	PlayerNameI    string `json:"playerNameI"`
+	XLegacy        int    `json:"xLegacy"`
+	YLegacy        int    `json:"yLegacy"`
+	ShotDistance   int    `json:"shotDistance"`
+	ShotResult     string `json:"shotResult"`
+	IsFieldGoal    int    `json:"isFieldGoal"`
+	ScoreHome      string `json:"scoreHome"`
+	ScoreAway      string `json:"scoreAway"`
+	PointsTotal    int    `json:"pointsTotal"`
+	Location       string `json:"location"`
+	Description    string `json:"description"`
+	ActionType     string `json:"actionType"`
+	SubType        string `json:"subType"`
+	VideoAvailable int    `json:"videoAvailable"`
+	ShotValue      int    `json:"shotValue"`
+	ActionID       int    `json:"actionId"`
+}
+
+var PBP_TBL = SetTbl{
+	Set:     "PlayByPlay",
+	Tbl:     "intake.pbp",
+	PrimKey: "game_id, action_number",
+}
+
+// games playbyplayv3 was called for, including ones that returned 0 actions
+var PBP_GAME_TBL = SetTbl{
+	Set:     "PlayByPlayGame",
+	Tbl:     "intake.pbp_game",
+	PrimKey: "game_id",
+	Upsert:  true,
+}
+
+func PBPReq(gameID string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/playbyplayv3",
+	}
+	gr.SetParams([]Pair{
+		{"GameID", gameID},
+		{"StartPeriod", "0"},
+		{"EndPeriod", "0"},
+	})
+	return gr
+}
+
+// actions as a PlayByPlay result set, headers match intake.pbp
+func (rp *RespPBP) ResultSet(gameID string) ResultSet {
+	rs := ResultSet{
+		Name: PBP_TBL.Set,
+		Headers: []string{
+			"GAME_ID", "ACTION_NUMBER", "CLOCK", "PERIOD", "TEAM_ID",
+			"TEAM_TRICODE", "PERSON_ID", "PLAYER_NAME", "PLAYER_NAME_I",
+			"X_LEGACY", "Y_LEGACY", "SHOT_DISTANCE", "SHOT_RESULT",
+			"IS_FIELD_GOAL", "SCORE_HOME", "SCORE_AWAY", "POINTS_TOTAL",
+			"LOCATION", "DESCRIPTION", "ACTION_TYPE", "SUB_TYPE",
+			"VIDEO_AVAILABLE", "SHOT_VALUE", "ACTION_ID",
+		},
+	}
+	for _, a := range rp.Game.Actions {
+		rs.RowSet = append(rs.RowSet, []any{
+			gameID, a.ActionNumber, a.Clock, a.Period, a.TeamID,
+			a.TeamTricode, a.PersonID, a.PlayerName, a.PlayerNameI,
+			a.XLegacy, a.YLegacy, a.ShotDistance, a.ShotResult,
+			a.IsFieldGoal, nullIfEmpty(a.ScoreHome), nullIfEmpty(a.ScoreAway),
+			a.PointsTotal, a.Location, a.Description, a.ActionType,
+			a.SubType, a.VideoAvailable, a.ShotValue, a.ActionID,
+		})
+	}
+	return rs
+}
+
+// one row marking gameID as fetched, actions is the count returned
+func (rp *RespPBP) GameSet(gameID string, fetched time.Time) ResultSet {
+	return ResultSet{
+		Name:    PBP_GAME_TBL.Set,
+		Headers: []string{"GAME_ID", "ACTIONS", "FETCHED_AT"},
+		RowSet: [][]any{{
+			gameID, len(rp.Game.Actions), fetched.UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+// nil for "" so it inserts as null instead of failing an int cast
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+/*
+fetch play by play for one game & load into intake.pbp
+the game is then recorded in intake.pbp_game so it isn't fetched again, even
+when the response had no actions
+*/
+func PBPETL(ctx context.Context, cnf *Conf, gameID string) error {
+	e := errd.InitErr()
+	r := PBPReq(gameID)
+	body, err := cnf.fetcher().Fetch(ctx, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting play by play for game %s: %v",
+			gameID, err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	var resp RespPBP
+	if err := json.Unmarshal(body, &resp); err != nil {
+		err = &BodyErr{newAPIErr(cnf.reqURL(r), body), err}
+		e.Msg = fmt.Sprintf("error unmarshaling play by play response: %v", err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	if len(resp.Game.Actions) == 0 {
+		cnf.L.WriteLog(fmt.Sprintf(
+			"play by play for game %s returned 0 actions", gameID))
+	}
+	if err := LoadSet(ctx, cnf, resp.ResultSet(gameID), PBP_TBL); err != nil {
+		return err
+	}
+	return LoadSet(ctx, cnf, resp.GameSet(gameID, cnf.Now()), PBP_GAME_TBL)
+}
+
+/*
+play by play for games in intake.gm_team matching f not yet in intake.pbp_game
+daily: GameFilter{Date: yesterday}, backfill: GameFilter{Szn: "2024"}
+*/
+func PBPsETL(ctx context.Context, cnf *Conf, f GameFilter) error {
+	e := errd.InitErr()
+	gids, err := NewGameIDs(ctx, cnf, PBP_GAME_TBL.Tbl, f)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting new game ids for %+v", f)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return PerGameETL(ctx, cnf, "play by play", gids,
+		func(gid string) error {
+			return PBPETL(ctx, cnf, gid)
+		})
+}
@@ -0,0 +1,59 @@
+package etl
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+// a game with no actions is still recorded in intake.pbp_game
+func TestPBPsETLEmptyGame(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, "")
+	cnf.Clock = fixtureClock
+
+	fdb.Rows["from intake.pbp_game b"] = [][]driver.Value{
+		{int64(22400561)}, {int64(22400562)},
+	}
+	full, empty := PBPReq("0022400561"), PBPReq("0022400562")
+	cnf.F = &MemFetcher{Bodies: map[string][]byte{
+		full.Key(): []byte(`{"game": {"gameId": "0022400561", "actions": [
+			{"actionNumber": 1, "clock": "PT12M00.00S", "period": 1,
+			"scoreHome": "0", "scoreAway": "0", "actionType": "period"},
+			{"actionNumber": 2, "clock": "PT11M40.00S", "period": 1,
+			"teamId": 1610612747, "personId": 2544, "scoreHome": "",
+			"scoreAway": "", "actionType": "Missed Shot"}]}}`),
+		empty.Key(): []byte(`{"game": {"gameId": "0022400562", "actions": []}}`),
+	}}
+
+	err := PBPsETL(context.Background(), cnf, GameFilter{Szn: "2024"})
+	if err != nil {
+		t.Fatalf("PBPsETL: %v", err)
+	}
+
+	pbp := fdb.execs("insert into intake.pbp (")
+	if len(pbp) != 1 || len(pbp[0].Args) != 2*24 {
+		t.Errorf("inserts into intake.pbp = %v, want 1 insert of 2 actions", pbp)
+	}
+	games := fdb.execs("insert into intake.pbp_game (")
+	if len(games) != 2 {
+		t.Fatalf("%d inserts into intake.pbp_game, want 2", len(games))
+	}
+	for i, want := range []struct {
+		gid     string
+		actions int
+	}{{"0022400561", 2}, {"0022400562", 0}} {
+		args := games[i].Args
+		if len(args) != 3 || args[0] != want.gid || args[1] != want.actions {
+			t.Errorf("intake.pbp_game row %d = %v, want %s with %d actions",
+				i, args, want.gid, want.actions)
+		}
+		if !strings.Contains(games[i].Qry, "do update set") {
+			t.Errorf("intake.pbp_game insert isn't an upsert: %s", games[i].Qry)
+		}
+	}
+	if len(cnf.Warns) > 0 {
+		t.Errorf("unexpected warnings (schema drift?): %v", cnf.Warns)
+	}
+}
@@ -0,0 +1,139 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+calls commonplayerinfo for height, weight, position, birthdate, school,
+country & draft details, upserted into intake.player_info with updated_at
+nightly: players new to lg.plr or not refreshed in PINFO_STALE_DAYS
+build: every player in each default league's intake player table
+*/
+
+const (
+	PINFO_STALE_DAYS = 30  // refresh player info older than this
+	PINFO_MAX        = 300 // max players per nightly run, oldest first
+)
+
+func PlayerInfoReq(playerID, league string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/commonplayerinfo",
+	}
+	gr.SetParams([]Pair{
+		{"PlayerID", playerID},
+		{"LeagueID", league},
+	})
+	return gr
+}
+
+// CommonPlayerInfo set, updated_at is added to every row for staleness
+func PlayerInfoTbl(updated time.Time) SetTbl {
+	return SetTbl{
+		Set:     "CommonPlayerInfo",
+		Tbl:     "intake.player_info",
+		PrimKey: "person_id",
+		Upsert:  true,
+		Consts:  []Pair{{"UPDATED_AT", updated.UTC().Format(time.RFC3339)}},
+	}
+}
+
+// fetch & upsert info for one player, league as the api id e.g. "00"
+func PlayerInfoETL(
+	ctx context.Context, cnf *Conf, playerID, league string,
+) error {
+	e := errd.InitErr()
+	r := PlayerInfoReq(playerID, league)
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: player %s",
+			r.Endpoint, playerID)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp, []SetTbl{PlayerInfoTbl(time.Now())})
+}
+
+/*
+player ids & their league for the player info run
+full: every player in the default leagues' commonallplayers tables
+otherwise players in lg.plr with no player info or info older than
+PINFO_STALE_DAYS, never loaded first, then oldest, up to PINFO_MAX
+*/
+func PlayerInfoIDs(
+	ctx context.Context, cnf *Conf, full bool,
+) (map[string]League, []string, error) {
+	var qry string
+	var args []any
+	if full {
+		var sel []string
+		for _, lg := range DefaultLeagues() {
+			sel = append(sel, fmt.Sprintf(
+				"select person_id, %d from %s", lg.LgID, lg.PlrTbl))
+		}
+		qry = strings.Join(sel, " union ") + " order by 1"
+	} else {
+		qry = fmt.Sprintf(`
+			select a.player_id, a.lg_id
+			from lg.plr a
+			left join intake.player_info b on b.person_id = a.player_id
+			where a.lg_id in (%s)
+			and (b.person_id is null
+				or b.updated_at < now() - make_interval(days => $1))
+			order by b.updated_at nulls first, a.player_id
+			limit $2`, lgIDList(DefaultLeagues()))
+		args = []any{PINFO_STALE_DAYS, PINFO_MAX}
+	}
+
+	rows, err := cnf.DB.QueryContext(ctx, qry, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	lgs := make(map[string]League)
+	var ids []string
+	for rows.Next() {
+		var pid int64
+		var lgID int
+		if err := rows.Scan(&pid, &lgID); err != nil {
+			return nil, nil, err
+		}
+		lg, ok := LeagueByLgID(lgID)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(pid)
+		if _, ok := lgs[id]; !ok {
+			ids = append(ids, id)
+		}
+		lgs[id] = lg
+	}
+	return lgs, ids, rows.Err()
+}
+
+/*
+player info for new & stale players (full: every player), a failed player is
+logged & retried on the next run
+*/
+func PlayerInfosETL(ctx context.Context, cnf *Conf, full bool) error {
+	e := errd.InitErr()
+	lgs, ids, err := PlayerInfoIDs(ctx, cnf, full)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting player ids for player info (full: %v)",
+			full)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return PerIDETL(ctx, cnf, "player info", "player", ids,
+		func(pid string) error {
+			return PlayerInfoETL(ctx, cnf, pid, lgs[pid].ID)
+		})
+}
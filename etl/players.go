@@ -1,148 +1,219 @@
 package etl
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/jdetok/golib/errd"
 )
 
+/*
+row of the commonallplayers CommonAllPlayers result set
+the nba & wnba responses have a few different columns, those are opt
+*/
+type PlayerRow struct {
+	PersonID       int64   `col:"PERSON_ID"`
+	LastCommaFirst string  `col:"DISPLAY_LAST_COMMA_FIRST"`
+	FirstLast      string  `col:"DISPLAY_FIRST_LAST"`
+	RosterStatus   *int    `col:"ROSTERSTATUS"`
+	FromYear       string  `col:"FROM_YEAR"`
+	ToYear         string  `col:"TO_YEAR"`
+	PlayerCode     string  `col:"PLAYERCODE"`
+	PlayerSlug     *string `col:"PLAYER_SLUG"`
+	TeamID         int64   `col:"TEAM_ID"`
+	TeamCity       *string `col:"TEAM_CITY"`
+	TeamName       *string `col:"TEAM_NAME"`
+	TeamAbbr       *string `col:"TEAM_ABBREVIATION"`
+	TeamSlug       *string `col:"TEAM_SLUG"`
+	TeamCode       *string `col:"TEAM_CODE"`
+	GamesPlayed    *string `col:"GAMES_PLAYED_FLAG"`
+	OtherLgExp     *string `col:"OTHERLEAGUE_EXPERIENCE_CH,opt"` // nba only
+	NBAAssigned    *string `col:"IS_NBA_ASSIGNED,opt"`           // wnba only
+	NBAAssignedTm  *string `col:"NBA_ASSIGNED_TEAM_ID,opt"`      // wnba only
+}
+
+// decode players by header name, logs extra columns, errors on missing ones
+func decodePlayers(cnf *Conf, rs ResultSet) ([]PlayerRow, error) {
+	prs, err := DecodeRows[PlayerRow](rs)
+	if err != nil && !OnlyExtra(err) {
+		return nil, err
+	} else if err != nil {
+		cnf.L.WriteLog(fmt.Sprintf("WARNING: %v", err))
+	}
+	var onTeam int
+	for _, p := range prs {
+		if p.TeamID > 0 {
+			onTeam++
+		}
+	}
+	cnf.L.WriteLog(fmt.Sprintf("%d players | %d on a team", len(prs), onTeam))
+	return prs, nil
+}
+
 func PlayerReq(onlyCurrent, league, season string) GetReq {
 	var gr = GetReq{
 		Host:     HOST,
 		Headers:  HDRS,
 		Endpoint: "/stats/commonallplayers",
-		Params: []Pair{
-			{"IsOnlyCurrentSeason", onlyCurrent},
-			{"LeagueID", league},
-			{"Season", season},
-		},
 	}
+	gr.SetParams([]Pair{
+		{"IsOnlyCurrentSeason", onlyCurrent},
+		{"LeagueID", league},
+		{"Season", season},
+	})
 	return gr
 }
 
-func PlayersParams() LgTbls {
-	var lt LgTbls
-	lt.lgs = []string{"00", "10"}
-	lt.tbls = []Table{
-		{
-			Name:    "intake.player",
-			PrimKey: "person_id",
-		},
-		{
-			Name:    "intake.wplayer",
-			PrimKey: "person_id",
-		},
-	}
-	return lt
-}
-
 // SAME AS CURRENT PLAYER ETL BUT FOR INDIVIDUAL SEASON
 // WILL NEED A NEW GET SEASONS FUNCTION AS WELL
-func SznPlayersETL(cnf Conf, onlyCurrent, season string) error {
-	e := errd.InitErr()
-	pp := PlayersParams()
-
+func SznPlayersETL(
+	ctx context.Context, cnf *Conf, onlyCurrent, season string,
+) error {
 	cnf.L.WriteLog(fmt.Sprintf(
-		"attempting players ETL for %s nba/wnba seasons",
-		season))
-	for i := range pp.lgs {
-		var lg string
-		switch pp.lgs[i] {
-		case "00":
-			lg = "nba"
-		case "10":
-			lg = "wnba"
+		"attempting players ETL for %s seasons", season))
+	for _, l := range DefaultLeagues() {
+		if err := LgPlayersETL(ctx, cnf, l, onlyCurrent, season); err != nil {
+			return err
 		}
+	}
+	cnf.L.WriteLog(fmt.Sprint("players ETL complete for ", season))
+	return nil
+}
 
-		cnf.L.WriteLog(fmt.Sprintf("attempting to insert %s %s players", season, lg))
-		// r := PlayerReq(onlyCurrent, p[0], p[1])
-		r := PlayerReq(onlyCurrent, pp.lgs[i], season)
-		resp, err := RequestResp(cnf.L, r)
-		if err != nil {
-			e.Msg = fmt.Sprintf("error getting response for %s: lg: %s szn: %s", r.Endpoint, lg, season)
-			cnf.L.WriteLog(e.Msg)
-			return e.BuildErr(err)
-		}
+// one league's players for season into the league's player table
+func LgPlayersETL(
+	ctx context.Context, cnf *Conf, l League, onlyCurrent, season string,
+) error {
+	e := errd.InitErr()
+	lg := l.Code
+	lSzn, _, started, err := l.SznOf(season)
+	if err != nil {
+		e.Msg = fmt.Sprintf("invalid season %s", season)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	if !started {
+		cnf.L.WriteLog(fmt.Sprintf("skipping %s players - no %s %s season",
+			season, l.Abbr, season))
+		return nil
+	}
 
-		// get cols/rows from resp, return early when no rows in response
-		var cols []string = resp.ResultSets[0].Headers
-		var rows [][]any = resp.ResultSets[0].RowSet
-		// ProcessResp(resp)
-		fmt.Println("Cols Length:", len(cols), "Rows Length:", len(rows))
+	cnf.L.WriteLog(fmt.Sprintf("attempting to insert %s %s players", lSzn, lg))
+	// r := PlayerReq(onlyCurrent, p[0], p[1])
+	r := PlayerReq(onlyCurrent, l.ID, lSzn)
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: lg: %s szn: %s", r.Endpoint, lg, season)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
 
-		if len(rows) == 0 {
-			cnf.L.WriteLog("response returned 0 rows, exiting")
-			return nil
-		}
-		cnf.L.WriteLog(
-			fmt.Sprintf("response returned %d fields & %d rows",
-				len(cols), len(rows)))
-
-		// prepare the sql statement & chunks of values
-		ins := MakeInsert(
-			pp.tbls[i].Name,
-			pp.tbls[i].PrimKey,
-			cols,
-			rows,
-		) // attempt to insert rows from response
-		ins.InsertFast(&cnf)
-
-		cnf.L.WriteLog(fmt.Sprintf("%s %s players ETL complete", season, lg))
+	st := SetTbl{
+		Set:     "CommonAllPlayers",
+		Tbl:     l.PlrTbl,
+		PrimKey: "person_id",
 	}
-	cnf.L.WriteLog(fmt.Sprint("players ETL complete for ", season))
+	rs, err := resp.Set(st.Set)
+	if err != nil {
+		e.Msg = fmt.Sprintf("unexpected response for %s: %v", r.Endpoint, err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	if _, err := decodePlayers(cnf, rs); err != nil {
+		e.Msg = fmt.Sprintf("unexpected player columns: %v", err)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	// attempt to insert rows from response
+	if err := LoadSet(ctx, cnf, rs, st); err != nil {
+		e.Msg = fmt.Sprintf("error inserting %s players", lg)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+
+	cnf.L.WriteLog(fmt.Sprintf("%s %s players ETL complete", season, lg))
 	return nil
 }
 
-func CrntPlayersETL(cnf Conf) error {
+/*
+current players for every default league, upserted so team changes are kept
+team changes from the stored players are recorded as transactions
+*/
+func CrntPlayersETL(ctx context.Context, cnf *Conf) error {
 	e := errd.InitErr()
-	sl := GetSeasons()
-	var szns = []string{sl.Szn, sl.WSzn}
-	pp := PlayersParams()
+	eff := cnf.NightlyDay() // same day as Yesterday
 
-	cnf.L.WriteLog(fmt.Sprintf(
-		"attempting current players ETL for %s nba season and %s wnba season",
-		sl.Szn, sl.WSzn))
-	for i := range pp.lgs {
-		var lg string
-		switch pp.lgs[i] {
-		case "00":
-			lg = "nba"
-		case "10":
-			lg = "wnba"
-		}
+	cnf.L.WriteLog("attempting current players ETL for all leagues")
+	for _, l := range DefaultLeagues() {
+		lg := l.Code
 
-		cnf.L.WriteLog(fmt.Sprintf("attempting to insert current %s players", lg))
+		cnf.L.WriteLog(fmt.Sprintf("attempting to insert current %s %s players",
+			cnf.CrntSzn(l), lg))
 		// r := PlayerReq(onlyCurrent, p[0], p[1])
-		r := PlayerReq("1", pp.lgs[i], szns[i])
-		resp, err := RequestResp(cnf.L, r)
+		r := PlayerReq("1", l.ID, cnf.CrntSzn(l))
+		resp, err := RequestResp(ctx, cnf, r)
 		if err != nil {
 			e.Msg = fmt.Sprintf("error getting response for %s", r.Endpoint)
 			cnf.L.WriteLog(e.Msg)
 			return e.BuildErr(err)
 		}
 
-		// get cols/rows from resp, return early when no rows in response
-		var cols []string = resp.ResultSets[0].Headers
-		var rows [][]any = resp.ResultSets[0].RowSet
-		// ProcessResp(resp)
-		fmt.Println("Cols Length:", len(cols), "Rows Length:", len(rows))
+		// existing players are updated so team changes aren't dropped
+		st := SetTbl{
+			Set:     "CommonAllPlayers",
+			Tbl:     l.PlrTbl,
+			PrimKey: "person_id",
+			Upsert:  true,
+		}
+		rs, err := resp.Set(st.Set)
+		if err != nil {
+			e.Msg = fmt.Sprintf("unexpected response for %s: %v", r.Endpoint, err)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		prs, err := decodePlayers(cnf, rs)
+		if err != nil {
+			e.Msg = fmt.Sprintf("unexpected player columns: %v", err)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
 
-		if len(rows) == 0 {
-			cnf.L.WriteLog("response returned 0 rows, exiting")
-			return nil
+		// compare to the stored teams before they're updated
+		stored, err := StoredTeams(ctx, cnf, st.Tbl)
+		if err != nil {
+			e.Msg = fmt.Sprintf("error getting stored %s player teams", lg)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		// a suspicious response still updates players, moves are skipped
+		trs, err := DetectTransactions(l.ID, stored, prs, eff)
+		if err != nil {
+			w := fmt.Sprintf("skipping %s transactions: %v", lg, err)
+			cnf.L.WriteLog(w)
+			cnf.Warns = append(cnf.Warns, w)
+		}
+
+		// attempt to insert rows from response
+		if err := LoadSet(ctx, cnf, rs, st); err != nil {
+			e.Msg = fmt.Sprintf("error inserting %s players", lg)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+
+		// waived players dropped from the response keep their team otherwise
+		if err := ClearTeams(ctx, cnf, st.Tbl, trs); err != nil {
+			e.Msg = fmt.Sprintf("error clearing waived %s players' teams", lg)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+
+		// players are updated, record the moves
+		if err := LoadTransactions(ctx, cnf, trs); err != nil {
+			e.Msg = fmt.Sprintf("error recording %s transactions", lg)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
 		}
-		cnf.L.WriteLog(
-			fmt.Sprintf("response returned %d fields & %d rows",
-				len(cols), len(rows)))
-
-		// prepare the sql statement & chunks of values
-		ins := MakeInsert(
-			pp.tbls[i].Name,
-			pp.tbls[i].PrimKey,
-			cols,
-			rows,
-		) // attempt to insert rows from response
-		ins.InsertFast(&cnf)
 
 		cnf.L.WriteLog(fmt.Sprintf("current %s players ETL complete", lg))
 	}
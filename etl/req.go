@@ -1,18 +1,24 @@
 package etl
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/jdetok/golib/errd"
-	"github.com/jdetok/golib/logd"
 )
 
 // build GetReq types to request data from new endpoints
 type GetReq struct {
+	Scheme   string // defaults to https when empty
 	Host     string
 	Endpoint string
-	Params   []Pair
+	Params   url.Values // plain values, encoded when the url is built
+	Order    []string   // optional key order for the query string
 	Headers  []Pair
 }
 
@@ -35,39 +41,84 @@ func (gr *GetReq) MakeFulLURL() string {
 make new request with url returned from MakeFullURL
 add gr.Headers to req with addHdrs
 use RespFromClient to do the http req, return the resp body []byte
+scheme & host come from hf.Base instead when it's set
 */
-func (gr *GetReq) BodyFromReq(l logd.Logger) ([]byte, error) {
+func (gr *GetReq) BodyFromReq(ctx context.Context, hf *HTTPFetcher) ([]byte, error) {
 	e := errd.InitErr()
-	req, err := http.NewRequest(http.MethodGet, gr.MakeFulLURL(), nil)
+	r := gr.withBase(hf.Base)
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, r.MakeFulLURL(), nil)
 	if err != nil {
-		e.Msg = fmt.Sprintf("error calling %s", gr.MakeFulLURL())
-		l.WriteLog(e.Msg)
+		e.Msg = fmt.Sprintf("error calling %s", r.MakeFulLURL())
+		hf.L.WriteLog(e.Msg)
 		return nil, e.BuildErr(err)
 
 	}
 	gr.addHdrs(req)
-	body, err := RespFromClient(l, req)
+	body, err := RespFromClient(hf, req)
 	if err != nil {
 		return nil, err
 	}
 	return body, nil
 }
 
-// concat endpoint to host
+// copy of gr with scheme & host replaced by base, gr itself if base is nil
+func (gr *GetReq) withBase(base *url.URL) *GetReq {
+	if base == nil {
+		return gr
+	}
+	r := *gr
+	r.Scheme = base.Scheme
+	r.Host = base.Host
+	return &r
+}
+
+// concat endpoint to scheme & host
 func (gr *GetReq) endptURL() string {
-	return "https://" + gr.Host + gr.Endpoint
+	scheme := gr.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return scheme + "://" + gr.Host + gr.Endpoint
 }
 
-// makes the query string from gr.Params
+/*
+set gr.Params from pairs of plain (unencoded) values, e.g. "Regular Season"
+gr.Order is set to the order passed so urls come out the same every time
+*/
+func (gr *GetReq) SetParams(ps []Pair) {
+	gr.Params = make(url.Values, len(ps))
+	gr.Order = make([]string, 0, len(ps))
+	for _, p := range ps {
+		gr.Params.Set(p.Key, p.Val)
+		gr.Order = append(gr.Order, p.Key)
+	}
+}
+
+/*
+makes the query string from gr.Params, keys in gr.Order come first in that
+order, any others follow sorted by key
+*/
 func (gr *GetReq) makeQryStr(bUrl string) string {
-	var url string = bUrl + "?"
-	for i, p := range gr.Params {
-		url = url + (p.Key + "=" + p.Val)
-		if i < len(gr.Params)-1 {
-			url += "&"
+	var qry []string
+	done := make(map[string]bool, len(gr.Params))
+	add := func(k string) {
+		for _, v := range gr.Params[k] {
+			qry = append(qry, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+		done[k] = true
+	}
+	for _, k := range gr.Order {
+		if _, ok := gr.Params[k]; ok && !done[k] {
+			add(k)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(gr.Params)) {
+		if !done[k] {
+			add(k)
 		}
 	}
-	return url
+	return bUrl + "?" + strings.Join(qry, "&")
 }
 
 // loop through gr.Headers & add each as a header to the request
@@ -1,19 +1,25 @@
 package etl
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/jdetok/golib/errd"
-	"github.com/jdetok/golib/logd"
 )
 
 type Resp struct {
 	Resource   string      `json:"resource"`
 	Parameters any         `json:"parameters"`
 	ResultSets []ResultSet `json:"resultSets"`
+	ResultSet  *ResultSet  `json:"resultSet"` // singular shape, some endpoints
+	url        string      // request url & body snippet for SetErr
+	snip       string
 }
 
 // main json object in response body after endpoint/params
@@ -23,73 +29,180 @@ type ResultSet struct {
 	RowSet  [][]any  `json:"rowSet"`
 }
 
-// pass a defined GetReq struct, unmarshals body & returns as Resp struct
-func RequestResp(l logd.Logger, gr GetReq) (Resp, error) {
+/*
+pass a defined GetReq struct, gets the body from cnf.F, unmarshals body &
+returns as Resp struct
+errors are *StatusErr, *BodyErr or *EmptyErr (wrapped) when the api sends back
+something other than result sets
+*/
+func RequestResp(ctx context.Context, cnf *Conf, gr GetReq) (Resp, error) {
 	e := errd.InitErr()
 	var resp Resp
-	body, err := gr.BodyFromReq(l)
+	url := cnf.reqURL(gr)
+	body, err := cnf.fetcher().Fetch(ctx, gr)
 	if err != nil {
-		e.Msg = fmt.Sprintf("error getting response for %s", gr.Endpoint)
-		l.WriteLog(e.Msg)
-		return resp, e.BuildErr(err)
+		e.Msg = fmt.Sprintf("error getting response for %s: %v", gr.Endpoint, err)
+		cnf.L.WriteLog(e.Msg)
+		return resp, fmt.Errorf("error getting response for %s: %w",
+			gr.Endpoint, err)
 	}
 	resp, err = UnmarshalInto(body)
 	if err != nil {
-		return resp, fmt.Errorf("error unmarshaling: %e", err)
+		err = &BodyErr{newAPIErr(url, body), err}
+		cnf.L.WriteLog(err.Error())
+		return resp, err
+	}
+	resp.url, resp.snip = url, snip(body)
+	if len(resp.ResultSets) == 0 {
+		err = &EmptyErr{newAPIErr(url, body)}
+		cnf.L.WriteLog(err.Error())
+		return resp, err
 	}
 	return resp, nil
 }
 
+// get result set by name, *SetErr if it isn't in the response
+func (resp *Resp) Set(name string) (ResultSet, error) {
+	var have []string
+	for _, rs := range resp.ResultSets {
+		if rs.Name == name {
+			return rs, nil
+		}
+		have = append(have, rs.Name)
+	}
+	return ResultSet{}, &SetErr{
+		APIErr{URL: resp.url, Snip: resp.snip}, name, have}
+}
+
 /*
 use http client to perform http request
 get & return body as []byte
+network errors, 429s & 5xx responses are retried per hf.Retry, every request
+that still fails after its retries counts against hf.Brk
+a 200 whose body isn't json (e.g. an html block page) is a *BodyErr, it isn't
+retried & counts against hf.Brk like a 403
+every attempt is paced by hf.Lim
+stops waiting/retrying as soon as the request's context is cancelled
 */
-func RespFromClient(l logd.Logger, req *http.Request) ([]byte, error) {
+func RespFromClient(hf *HTTPFetcher, req *http.Request) ([]byte, error) {
 	e := errd.InitErr()
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		if res != nil {
-			if res.StatusCode == 429 {
-				e.Msg = fmt.Sprint(res.StatusCode, "- timeout error")
-			} else {
-				e.Msg = fmt.Sprint(res.StatusCode, "- HTTP client error occured")
-			}
-			l.WriteLog(e.Msg)
-			return nil, e.BuildErr(err)
+	if hf.Brk.Open() {
+		hf.L.WriteLog(fmt.Sprintf("circuit breaker open, skipping %s", req.URL))
+		return nil, ErrBreakerOpen
+	}
+
+	var lastErr error
+	for a := range hf.Retry.attempts() {
+		// every attempt, retries included, waits its turn
+		if err := hf.Lim.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		body, res, err := doReq(hf.client(), req)
+		if err == nil && !json.Valid(body) {
+			err = &BodyErr{newAPIErr(req.URL.String(), body),
+				errors.New("body isn't valid json")}
+		}
+		if err == nil {
+			hf.Brk.Success()
+			return body, nil
+		}
+		lastErr = err
+
+		// cancelled, not the api's fault - don't retry or count it
+		if req.Context().Err() != nil {
+			return nil, req.Context().Err()
+		}
+
+		// non-retryable status (e.g. 403 or 200 block page), don't try again
+		if res != nil && !retryableStatus(res.StatusCode) {
+			break
 		}
-		e.Msg = "*500 - HTTP client error occured, no response received"
-		return nil, e.NewErr()
+		if a == hf.Retry.attempts()-1 {
+			break
+		}
+		wait := hf.Retry.wait(a, res)
+		hf.L.WriteLog(fmt.Sprintf(
+			"attempt %d/%d failed for %s: %v | retrying in %v",
+			a+1, hf.Retry.attempts(), req.URL, err, wait))
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	if hf.Brk.Failure() {
+		hf.L.WriteLog(fmt.Sprintf(
+			"%d consecutive failed requests - circuit breaker open, stopping requests",
+			hf.Brk.MaxFails))
+	}
+	e.Msg = fmt.Sprintf("request failed after %d attempt(s): %v",
+		hf.Retry.attempts(), lastErr)
+	hf.L.WriteLog(e.Msg)
+	return nil, fmt.Errorf("request failed after %d attempt(s): %w",
+		hf.Retry.attempts(), lastErr)
+}
+
+/*
+single attempt at the request, returns the response (nil if none received)
+so the caller can check the status & Retry-After header
+any non 2xx status is returned as a *StatusErr
+*/
+func doReq(c *http.Client, req *http.Request) ([]byte, *http.Response, error) {
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("HTTP client error, no response received: %w", err)
 	}
 
 	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		e.Msg = fmt.Sprint(res.StatusCode, "- error reading response body")
-		l.WriteLog(e.Msg)
-		return nil, e.BuildErr(err)
+		return nil, res, fmt.Errorf("%d - error reading response body: %w",
+			res.StatusCode, err)
 	}
-	return body, nil
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, res, &StatusErr{
+			newAPIErr(req.URL.String(), body), res.StatusCode}
+	}
+	return body, res, nil
 }
 
 /*
 pass resp returned from RequestResp
-placeholder print `header - val` to console
+placeholder print `header - val` to console for every result set
 */
 func ProcessResp(resp Resp) {
-	// fmt.Println(resp.ResultSets[0].RowSet[0]...)
-	for _, r := range resp.ResultSets[0].RowSet {
-		for i, x := range r {
-			fmt.Printf("%v: %v\n", resp.ResultSets[0].Headers[i], x)
+	if len(resp.ResultSets) == 0 {
+		fmt.Println("no result sets in response")
+		return
+	}
+	for _, rs := range resp.ResultSets {
+		fmt.Printf("======= %s\n", rs.Name)
+		for _, r := range rs.RowSet {
+			for i, x := range r {
+				fmt.Printf("%v: %v\n", rs.Headers[i], x)
+			}
+			fmt.Println("*******")
 		}
-		fmt.Println("*******")
 	}
 }
 
-// unmarshal []byte body into Resp struct
+/*
+unmarshal []byte body into Resp struct
+numbers in the rowSets are kept as json.Number instead of float64 so large ids
+keep their precision, they're inserted as their string form
+responses with a single "resultSet" are normalized into ResultSets
+*/
 func UnmarshalInto(body []byte) (Resp, error) {
 	var resp Resp
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return resp, fmt.Errorf("error unmarshaling: %e", err)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&resp); err != nil {
+		return resp, fmt.Errorf("error unmarshaling: %w", err)
+	}
+	// singular resultSet, treat it like a one item resultSets
+	if len(resp.ResultSets) == 0 && resp.ResultSet != nil {
+		resp.ResultSets = []ResultSet{*resp.ResultSet}
 	}
 	return resp, nil
 }
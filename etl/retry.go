@@ -0,0 +1,138 @@
+package etl
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// returned by RespFromClient once the breaker has tripped
+var ErrBreakerOpen = errors.New("circuit breaker open: too many consecutive failed requests")
+
+/*
+retry policy for requests to the api
+each failed attempt waits a random duration between 0 and
+BaseDelay * 2^attempt (full jitter), capped at MaxDelay
+a Retry-After header on a 429/503 overrides the backoff (also capped)
+*/
+type RetryPolicy struct {
+	MaxAttempts int           // total tries per request, <= 1 disables retry
+	BaseDelay   time.Duration // backoff ceiling for the first retry
+	MaxDelay    time.Duration // max wait between any two attempts
+}
+
+func DefaultRetry() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    60 * time.Second,
+	}
+}
+
+func (rp RetryPolicy) attempts() int {
+	return max(rp.MaxAttempts, 1)
+}
+
+// full jitter backoff for the (0 based) attempt that just failed
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	if rp.BaseDelay <= 0 {
+		return 0
+	}
+	ceil := rp.BaseDelay << min(attempt, 16)
+	if rp.MaxDelay > 0 && (ceil > rp.MaxDelay || ceil <= 0) {
+		ceil = rp.MaxDelay
+	}
+	return rand.N(ceil + 1)
+}
+
+// wait before the next attempt, prefer the server's Retry-After when sent
+func (rp RetryPolicy) wait(attempt int, res *http.Response) time.Duration {
+	if ra, ok := retryAfter(res); ok {
+		if rp.MaxDelay > 0 && ra > rp.MaxDelay {
+			return rp.MaxDelay
+		}
+		return ra
+	}
+	return rp.backoff(attempt)
+}
+
+// retry on 429 (throttled), 408 (timeout) & any 5xx
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout ||
+		code >= 500
+}
+
+// parse Retry-After as either delay seconds or an HTTP date
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	ra := res.Header.Get("Retry-After")
+	if ra == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(ra); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(ra); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+/*
+circuit breaker shared by every request in a run
+trips after MaxFails requests in a row fail (after their retries), once open
+all further requests fail immediately with ErrBreakerOpen so the run can stop
+instead of hammering a host that is blocking us
+a nil *Breaker never trips
+*/
+type Breaker struct {
+	MaxFails int
+	mu       sync.Mutex
+	fails    int
+	open     bool
+}
+
+func NewBreaker(maxFails int) *Breaker {
+	return &Breaker{MaxFails: maxFails}
+}
+
+// true once the breaker has tripped
+func (b *Breaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// reset the consecutive failure count after a successful request
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.fails = 0
+	b.mu.Unlock()
+}
+
+// record a failed request, returns true if this failure tripped the breaker
+func (b *Breaker) Failure() bool {
+	if b == nil || b.MaxFails <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fails++
+	if !b.open && b.fails >= b.MaxFails {
+		b.open = true
+		return true
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/*
+api stand-in answering each request with the next code in codes (200 after
+they run out), 200s send json unless html is set, 429/503s send Retry-After ra
+*/
+type apiStub struct {
+	codes []int
+	ra    string
+	html  bool
+	hits  atomic.Int32
+}
+
+func (as *apiStub) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	code := http.StatusOK
+	if n := int(as.hits.Add(1)); n <= len(as.codes) {
+		code = as.codes[n-1]
+	}
+	switch {
+	case code == http.StatusOK && as.html:
+		w.Write([]byte("<html>Access Denied</html>"))
+	case code == http.StatusOK:
+		w.Write([]byte(`{"resultSets":[]}`))
+	default:
+		if as.ra != "" {
+			w.Header().Set("Retry-After", as.ra)
+		}
+		w.WriteHeader(code)
+	}
+}
+
+// fetcher pointed at as through -api-base, no limiter
+func stubFetcher(
+	t *testing.T, as *apiStub, rp RetryPolicy, brk *Breaker,
+) *HTTPFetcher {
+	t.Helper()
+	srv := httptest.NewServer(as)
+	t.Cleanup(srv.Close)
+	base, err := ParseBase(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, db := newFakeDB(t)
+	cnf := testConf(t, db, "")
+	return &HTTPFetcher{L: &cnf.L, Base: base, Retry: rp, Brk: brk}
+}
+
+// fetch with a deadline, a backoff that ignored Retry-After would hang
+func stubFetch(t *testing.T, hf *HTTPFetcher) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := hf.Fetch(ctx, PlayerReq("1", "00", "2024-25"))
+	if ctx.Err() != nil {
+		t.Fatalf("request still waiting after 5s: %v", err)
+	}
+	return err
+}
+
+func TestRespFromClientRetry(t *testing.T) {
+	// backoff alone would wait up to an hour, Retry-After: 0 retries now
+	rp := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	tests := []struct {
+		name  string
+		codes []int
+		hits  int32
+		code  int // *StatusErr code, 0 for success
+	}{
+		{"429 then ok", []int{429}, 2, 0},
+		{"503 twice then ok", []int{503, 503}, 3, 0},
+		{"503 every attempt", []int{503, 503, 503}, 3, 503},
+		{"403 not retried", []int{403}, 1, 403},
+		{"404 not retried", []int{404}, 1, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := &apiStub{codes: tt.codes, ra: "0"}
+			err := stubFetch(t, stubFetcher(t, as, rp, nil))
+			var se *StatusErr
+			switch {
+			case tt.code == 0 && err != nil:
+				t.Errorf("err = %v, want success", err)
+			case tt.code != 0 && (!errors.As(err, &se) || se.Code != tt.code):
+				t.Errorf("err = %v, want %d *StatusErr", err, tt.code)
+			}
+			if got := as.hits.Load(); got != tt.hits {
+				t.Errorf("%d requests, want %d", got, tt.hits)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		ra   string
+		min  time.Duration
+		max  time.Duration
+		want bool
+	}{
+		{"0", 0, 0, true},
+		{"30", 30 * time.Second, 30 * time.Second, true},
+		{date, 58 * time.Minute, time.Hour, true},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, 0, true}, // past date, no wait
+		{"", 0, 0, false},
+		{"soon", 0, 0, false},
+		{"-5", 0, 0, false},
+	}
+	for _, tt := range tests {
+		res := &http.Response{Header: http.Header{}}
+		if tt.ra != "" {
+			res.Header.Set("Retry-After", tt.ra)
+		}
+		got, ok := retryAfter(res)
+		if ok != tt.want || got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v-%v, %v",
+				tt.ra, got, ok, tt.min, tt.max, tt.want)
+		}
+	}
+	if _, ok := retryAfter(nil); ok {
+		t.Error("retryAfter(nil) found a Retry-After")
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	rp := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	// Retry-After wins over the backoff but is capped at MaxDelay
+	res := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+	if got := rp.wait(0, res); got != 2*time.Second {
+		t.Errorf("wait with Retry-After 2 = %v, want 2s", got)
+	}
+	res.Header.Set("Retry-After", "120")
+	if got := rp.wait(0, res); got != rp.MaxDelay {
+		t.Errorf("wait with Retry-After 120 = %v, want %v", got, rp.MaxDelay)
+	}
+
+	// full jitter: 0 - BaseDelay * 2^attempt, never over MaxDelay
+	for a, ceil := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second,
+		5 * time.Second,
+	} {
+		for range 100 {
+			if got := rp.wait(a, nil); got < 0 || got > ceil {
+				t.Fatalf("wait(%d) = %v, want 0 - %v", a, got, ceil)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).wait(3, nil); got != 0 {
+		t.Errorf("wait with no BaseDelay = %v, want 0", got)
+	}
+}
+
+func TestBreakerOpens(t *testing.T) {
+	rp := RetryPolicy{MaxAttempts: 2}
+	tests := []struct {
+		name  string
+		as    *apiStub
+		calls int
+		open  bool
+		hits  int32 // requests that reached the api
+	}{
+		// 2 attempts per call, the 4th call never reaches the api
+		{"503s trip after 3 calls",
+			&apiStub{codes: []int{503, 503, 503, 503, 503, 503}},
+			4, true, 6},
+		{"a success resets the count",
+			&apiStub{codes: []int{503, 503, 503, 503, 200, 503, 503}},
+			4, false, 7},
+		// a 200 block page isn't retried & counts as a failed request
+		{"200 block pages trip after 3 calls",
+			&apiStub{html: true}, 4, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := NewBreaker(3)
+			hf := stubFetcher(t, tt.as, rp, brk)
+			var err error
+			for range tt.calls {
+				err = stubFetch(t, hf)
+			}
+			if brk.Open() != tt.open {
+				t.Errorf("breaker open = %v, want %v", brk.Open(), tt.open)
+			}
+			if tt.open && !errors.Is(err, ErrBreakerOpen) {
+				t.Errorf("last call err = %v, want %v", err, ErrBreakerOpen)
+			}
+			if got := tt.as.hits.Load(); got != tt.hits {
+				t.Errorf("%d requests, want %d", got, tt.hits)
+			}
+		})
+	}
+}
+
+// block pages come back as *BodyErr naming the url requested
+func TestRespFromClientBlockPage(t *testing.T) {
+	as := &apiStub{html: true}
+	err := stubFetch(t, stubFetcher(t, as, DefaultRetry(), nil))
+	var be *BodyErr
+	if !errors.As(err, &be) {
+		t.Fatalf("err = %v, want *BodyErr", err)
+	}
+	if as.hits.Load() != 1 {
+		t.Errorf("%d requests, want 1", as.hits.Load())
+	}
+}
@@ -0,0 +1,114 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+calls commonteamroster for every team in lg.team, players & coaches
+are stored as dated snapshots in intake.roster & intake.coach
+lg.sp_plr_crnt fills lg.plr_crnt from each team's latest snapshot
+*/
+
+func RosterReq(teamID, league, season string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/commonteamroster",
+	}
+	gr.SetParams([]Pair{
+		{"TeamID", teamID},
+		{"Season", season},
+		{"LeagueID", league},
+	})
+	return gr
+}
+
+/*
+roster & coaches sets with SNAP_DATE added to every row, one snapshot per team
+per day, a second run on the same day is skipped by the primary keys
+the wnba response leaves out a few player columns, only shared ones are loaded
+*/
+func RosterSets(snap time.Time) []SetTbl {
+	var sd = []Pair{{"SNAP_DATE", snap.Format("2006-01-02")}}
+	return []SetTbl{
+		{
+			Set:     "CommonTeamRoster",
+			Tbl:     "intake.roster",
+			PrimKey: "snap_date, teamid, player_id",
+			Drift:   DRIFT_SHARED,
+			Consts:  sd,
+		},
+		{
+			Set:     "Coaches",
+			Tbl:     "intake.coach",
+			PrimKey: "snap_date, team_id, coach_id",
+			Drift:   DRIFT_SHARED,
+			Consts:  sd,
+			Opt:     true,
+		},
+	}
+}
+
+// fetch one team's roster & coaches & load today's snapshot
+func RosterETL(
+	ctx context.Context, cnf *Conf, teamID, league, season string,
+) error {
+	e := errd.InitErr()
+	r := RosterReq(teamID, league, season)
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: team %s",
+			r.Endpoint, teamID)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp, RosterSets(GameDay(cnf.Now())))
+}
+
+// every team id in lg.team for the league
+func RosterTeams(ctx context.Context, cnf *Conf, lg League) ([]string, error) {
+	rows, err := cnf.DB.QueryContext(ctx, `
+		select team_id from lg.team
+		where lg_id = $1 and team_id > 0
+		order by team_id`, lg.LgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var tid int64
+		if err := rows.Scan(&tid); err != nil {
+			return nil, err
+		}
+		ids = append(ids, fmt.Sprint(tid))
+	}
+	return ids, rows.Err()
+}
+
+// today's roster & coach snapshot for every team in the default leagues
+func RostersETL(ctx context.Context, cnf *Conf) error {
+	e := errd.InitErr()
+	for _, lg := range DefaultLeagues() {
+		tids, err := RosterTeams(ctx, cnf, lg)
+		if err != nil {
+			e.Msg = fmt.Sprintf(
+				"error getting current %s teams for roster ETL", lg.Abbr)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		if err := PerIDETL(ctx, cnf, lg.Code+" team roster", "team", tids,
+			func(tid string) error {
+				return RosterETL(ctx, cnf, tid, lg.ID, cnf.CrntSzn(lg))
+			}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
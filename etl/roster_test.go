@@ -0,0 +1,55 @@
+package etl
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// every lg.team team gets a roster call, games in intake.gm_team don't matter
+func TestRostersETLTeams(t *testing.T) {
+	fdb, db := newFakeDB(t)
+	cnf := testConf(t, db, "")
+	cnf.Clock = fixtureClock
+
+	fdb.Rows["from lg.team"] = [][]driver.Value{
+		{int64(1610612747)}, {int64(1610612738)},
+	}
+	mf := &MemFetcher{Bodies: map[string][]byte{
+		"/stats/commonteamroster": []byte(`{"resultSets": [
+			{"name": "CommonTeamRoster", "headers": ["TeamID"], "rowSet": []},
+			{"name": "Coaches", "headers": ["TEAM_ID"], "rowSet": []}]}`),
+	}}
+	cnf.F = mf
+
+	if err := RostersETL(context.Background(), cnf); err != nil {
+		t.Fatalf("RostersETL: %v", err)
+	}
+
+	var lgs []any
+	for _, q := range fdb.Qrys {
+		if !strings.Contains(q.Qry, "from lg.team") {
+			continue
+		}
+		if strings.Contains(q.Qry, "intake.gm_team") {
+			t.Errorf("team query depends on intake.gm_team: %s", q.Qry)
+		}
+		lgs = append(lgs, q.Args...)
+	}
+	if fmt.Sprint(lgs) != "[0 1]" {
+		t.Errorf("lg.team queried for lg_ids %v, want [0 1]", lgs)
+	}
+
+	var got []string
+	for _, r := range mf.Reqs {
+		got = append(got, r.Params.Get("TeamID")+"/"+r.Params.Get("LeagueID"))
+	}
+	want := []string{
+		"1610612747/00", "1610612738/00", "1610612747/10", "1610612738/10",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("roster requests (team/league) = %v, want %v", got, want)
+	}
+}
@@ -1,14 +1,19 @@
 package etl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/jdetok/golib/errd"
-	"github.com/jdetok/golib/logd"
 )
 
-// calls scheduleleaguev2 endpoint for schedule start/end dates
+/*
+calls scheduleleaguev2 for every game in a league's season & loads it into
+intake.schedule, the nightly run reads it to skip days without games
+the response isn't result sets, games are nested under each date & turned
+into a Schedule ResultSet like play by play
+*/
 
 type RespSched struct {
 	Dates GameDates `json:"leagueSchedule"`
@@ -16,11 +21,40 @@ type RespSched struct {
 
 // main json object in response body after endpoint/params
 type GameDates struct {
-	GmDates []GameDate `json:"gameDates"`
+	SeasonYear string     `json:"seasonYear"`
+	LeagueID   string     `json:"leagueId"`
+	GmDates    []GameDate `json:"gameDates"`
 }
 
 type GameDate struct {
-	Date string `json:"gameDate"`
+	Date  string      `json:"gameDate"`
+	Games []SchedGame `json:"games"`
+}
+
+type SchedGame struct {
+	GameID         string    `json:"gameId"`
+	GameCode       string    `json:"gameCode"`
+	GameStatus     int       `json:"gameStatus"`
+	GameStatusText string    `json:"gameStatusText"`
+	GameDateEst    string    `json:"gameDateEst"` // 2024-10-22T00:00:00Z
+	GameLabel      string    `json:"gameLabel"`
+	ArenaName      string    `json:"arenaName"`
+	HomeTeam       SchedTeam `json:"homeTeam"`
+	AwayTeam       SchedTeam `json:"awayTeam"`
+}
+
+type SchedTeam struct {
+	TeamID      int64  `json:"teamId"`
+	TeamTricode string `json:"teamTricode"`
+	Score       int    `json:"score"`
+}
+
+// statuses & scores change every night, existing games are updated
+var SCHED_TBL = SetTbl{
+	Set:     "Schedule",
+	Tbl:     "intake.schedule",
+	PrimKey: "game_id",
+	Upsert:  true,
 }
 
 func SchedReq(league, season string) GetReq {
@@ -28,30 +62,158 @@ func SchedReq(league, season string) GetReq {
 		Host:     HOST,
 		Headers:  HDRS,
 		Endpoint: "/stats/scheduleleaguev2",
-		Params: []Pair{
-			{"LeagueID", league},
-			{"Season", season},
-		},
 	}
+	gr.SetParams([]Pair{
+		{"LeagueID", league},
+		{"Season", season},
+	})
 	return gr
 }
 
-func RequestSchedule(l logd.Logger, gr GetReq) error {
+func RequestSchedule(ctx context.Context, cnf *Conf, gr GetReq) (RespSched, error) {
 	e := errd.InitErr()
-	fmt.Printf("requesting data from %s...\n", gr.Endpoint)
-	body, err := gr.BodyFromReq(l)
+	var resp RespSched
+	body, err := cnf.fetcher().Fetch(ctx, gr)
 	if err != nil {
-		e.Msg = "error getting schedule response"
-		return e.BuildErr(err)
+		e.Msg = fmt.Sprintf("error getting schedule response: %v", err)
+		cnf.L.WriteLog(e.Msg)
+		return resp, e.BuildErr(err)
 	}
 
-	var resp RespSched
 	if err := json.Unmarshal(body, &resp); err != nil {
-		e.Msg = "error unmarshaling schedule response"
-		fmt.Println(err)
+		err = &BodyErr{newAPIErr(cnf.reqURL(gr), body), err}
+		e.Msg = fmt.Sprintf("error unmarshaling schedule response: %v", err)
+		cnf.L.WriteLog(e.Msg)
+		return resp, e.BuildErr(err)
+	}
+	return resp, nil
+}
+
+// every game as a Schedule result set, headers match intake.schedule
+func (rs *RespSched) ResultSet(league string) ResultSet {
+	out := ResultSet{
+		Name: SCHED_TBL.Set,
+		Headers: []string{
+			"GAME_ID", "LG_ID", "SEASON", "GAME_DATE", "GAME_CODE",
+			"GAME_STATUS", "GAME_STATUS_TEXT", "GAME_LABEL", "ARENA_NAME",
+			"HOME_TEAM_ID", "HOME_TEAM_TRICODE", "HOME_SCORE",
+			"AWAY_TEAM_ID", "AWAY_TEAM_TRICODE", "AWAY_SCORE",
+		},
+	}
+	for _, d := range rs.Dates.GmDates {
+		for _, g := range d.Games {
+			var gDate string = g.GameDateEst
+			if len(gDate) >= 10 {
+				gDate = gDate[:10]
+			}
+			out.RowSet = append(out.RowSet, []any{
+				g.GameID, league, rs.Dates.SeasonYear, nullIfEmpty(gDate),
+				g.GameCode, g.GameStatus, g.GameStatusText, g.GameLabel,
+				g.ArenaName, g.HomeTeam.TeamID, g.HomeTeam.TeamTricode,
+				g.HomeTeam.Score, g.AwayTeam.TeamID, g.AwayTeam.TeamTricode,
+				g.AwayTeam.Score,
+			})
+		}
+	}
+	return out
+}
+
+// fetch a league's season schedule & upsert it into intake.schedule
+func SchedETL(ctx context.Context, cnf *Conf, league, season string) error {
+	e := errd.InitErr()
+	r := SchedReq(league, season)
+	cnf.L.WriteLog(fmt.Sprintf(
+		"attempting to fetch %s: LG=%s, SZN=%s", r.Endpoint, league, season))
+	resp, err := RequestSchedule(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting schedule. LG=%s, SZN=%s",
+			league, season)
+		cnf.L.WriteLog(e.Msg)
 		return e.BuildErr(err)
 	}
+	return LoadSet(ctx, cnf, resp.ResultSet(league), SCHED_TBL)
+}
 
-	fmt.Println(resp.Dates)
+// current schedule for every default league, run before the nightly game logs
+func SchedDailyETL(ctx context.Context, cnf *Conf) error {
+	e := errd.InitErr()
+	for _, lg := range DefaultLeagues() {
+		if err := SchedETL(ctx, cnf, lg.ID, cnf.CrntSzn(lg)); err != nil {
+			e.Msg = fmt.Sprintf("error during daily schedule ETL. LG=%s, SZN=%s",
+				lg.Code, cnf.CrntSzn(lg))
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+	return nil
+}
+
+// a game from intake.schedule, see SchedGames
+type SchedRow struct {
+	GameID     string
+	Lg         string
+	Status     string
+	Home, Away string
+}
+
+/*
+games in intake.schedule on date (MM/DD/YYYY), missing only returns the ones
+with no intake.gm_team rows, i.e. scheduled but not in the game logs
+*/
+func SchedGames(
+	ctx context.Context, cnf *Conf, date string, missing bool,
+) ([]SchedRow, error) {
+	qry := `
+		select a.game_id, a.lg_id, coalesce(a.game_status_text, ''),
+			coalesce(a.home_team_tricode, ''), coalesce(a.away_team_tricode, '')
+		from intake.schedule a
+		where a.game_date = to_date($1, 'MM/DD/YYYY')`
+	if missing {
+		qry += `
+		and not exists (
+			select 1 from intake.gm_team b where b.game_id = a.game_id)`
+	}
+	qry += " order by a.game_id"
+
+	rows, err := cnf.DB.QueryContext(ctx, qry, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gms []SchedRow
+	for rows.Next() {
+		var g SchedRow
+		var gid int64
+		if err := rows.Scan(
+			&gid, &g.Lg, &g.Status, &g.Home, &g.Away); err != nil {
+			return nil, err
+		}
+		g.GameID = fmt.Sprintf("%010d", gid)
+		gms = append(gms, g)
+	}
+	return gms, rows.Err()
+}
+
+/*
+log scheduled games on date that never showed up in the game logs, e.g.
+postponed games or a game log response missing a game
+each is added to cnf.Warns for the run summary
+*/
+func LogMissingGames(ctx context.Context, cnf *Conf, date string) error {
+	e := errd.InitErr()
+	gms, err := SchedGames(ctx, cnf, date, true)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error checking %s schedule for missing games", date)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	for _, g := range gms {
+		w := fmt.Sprintf(
+			"scheduled game %s (LG=%s %s @ %s, status: %s) on %s not in game logs",
+			g.GameID, g.Lg, g.Away, g.Home, g.Status, date)
+		cnf.L.WriteLog(fmt.Sprintf("WARNING: %s", w))
+		cnf.Warns = append(cnf.Warns, w)
+	}
 	return nil
 }
@@ -2,110 +2,104 @@ package etl
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
-
-	"github.com/jdetok/golib/errd"
-	"github.com/jdetok/golib/logd"
 )
 
-type SeasonLeague struct {
-	Szn  string
-	WSzn string
-}
+/*
+a season by its start year, 2024 is the 2024-25 nba & the 2024 wnba season
+String() is the nba style span, League.Szn formats for a league's api requests
+*/
+type Season int
 
-// pass a time (usually time.Now()), return string with yesterday's date
+// pass a time (usually cnf.Now()), return the previous ET game day's date
 func Yesterday(dt time.Time) string {
-	return dt.Add(-24 * time.Hour).Format("01/02/2006")
+	return GameDay(dt).AddDate(0, 0, -1).Format("01/02/2006")
 }
 
-func SznBSlice(l logd.Logger, start, end string) ([]string, error) {
-	e := errd.InitErr()
-	startYr, errS := strconv.Atoi(start)
-	endYr, errE := strconv.Atoi(end)
-	numY := endYr - startYr
-
-	if errS != nil || errE != nil {
-		e.Msg = "error converting start or end year to int"
-		l.WriteLog(e.Msg)
-		return nil, e.NewErr()
-	}
-
-	var szns []string
-	for y := numY; y > 0; y-- {
-		szns = append(szns,
-			fmt.Sprintf(
-				"%d-%s", startYr+y, strconv.Itoa(startYr + (y + 1))[2:]),
-		)
+/*
+season from a -szn style string: 2024-25 (nba style span), 2024 (wnba style
+year) or a 5 digit season_id like 22024 (season type prefix is dropped)
+*/
+func ParseSeason(s string) (Season, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case len(s) == 5 && !strings.Contains(s, "-"):
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid season '%s': %w", s, err)
+		}
+		szn, _, err := ParseSeasonID(id)
+		return szn, err
+	case len(s) != 4 && len(s) != 7:
+		return 0, fmt.Errorf(
+			"invalid season '%s': must be e.g. 2024-25, 2024 or 22024", s)
 	}
-	szns = append(szns, fmt.Sprintf("%d-%s", startYr, strconv.Itoa(startYr + 1)[2:]))
-	return szns, nil
-}
-
-func SznSlice(l logd.Logger, start, end string) ([]string, error) {
-	e := errd.InitErr()
-	startYr, errS := strconv.Atoi(start)
-	endYr, errE := strconv.Atoi(end)
-	numY := endYr - startYr
-
-	if errS != nil || errE != nil {
-		e.Msg = "error converting start or end year to int"
-		l.WriteLog(e.Msg)
-		return nil, e.NewErr()
+	y, err := strconv.Atoi(s[:4])
+	if err != nil || y < 1000 {
+		return 0, fmt.Errorf("invalid season '%s': bad start year", s)
 	}
-
-	var szns []string
-	for y := range numY {
-		szns = append(szns,
-			fmt.Sprintf(
-				"%d-%s", startYr+y, strconv.Itoa(startYr + (y + 1))[2:]),
-		)
+	szn := Season(y)
+	if len(s) == 7 && s != szn.String() {
+		return 0, fmt.Errorf("invalid season '%s': expected %s", s, szn)
 	}
-	szns = append(szns, fmt.Sprintf("%d-%s", endYr, strconv.Itoa(endYr + 1)[2:]))
-	return szns, nil
+	return szn, nil
 }
 
 /*
-returns slice of season strings for date (generally pass time.Now())
-calling in 2025 will return 2024-25 and 2025-26 and so on
+season & season type (lg.szn_type id, e.g. 4 for playoffs) from a game log's
+season_id, 42024 is the 2024-25 nba / 2024 wnba playoffs
 */
-func CurrentSzns(dt time.Time) []string {
-	var cyyy string = dt.Format("2006")
-	var cy string = dt.AddDate(1, 0, 0).Format("06")
-
-	var pyyy string = dt.AddDate(-1, 0, 0).Format("2006")
-	var py string = dt.Format("06")
-
-	return []string{
-		fmt.Sprint(pyyy, "-", py),
-		fmt.Sprint(cyyy, "-", cy),
+func ParseSeasonID(id int) (Season, int, error) {
+	st, y := id/10000, id%10000
+	if !slices.ContainsFunc(SZN_TYPES, func(t SznType) bool {
+		return t.ID == st
+	}) || y < 1000 {
+		return 0, 0, fmt.Errorf("invalid season_id %d", id)
 	}
+	return Season(y), st, nil
 }
 
-func GetSeasons() SeasonLeague {
-	var sl SeasonLeague
-	var crnt []string = CurrentSzns(time.Now())
+// nba style span, 2024-25 (1999-00 across the century)
+func (s Season) String() string {
+	return fmt.Sprintf("%d-%02d", int(s), (int(s)+1)%100)
+}
+
+// start year, 2024
+func (s Season) Yr() string {
+	return strconv.Itoa(int(s))
+}
 
-	m, err := strconv.Atoi(time.Now().Format("1"))
-	if err != nil {
-		fmt.Println(err)
+// SZN_SPAN: 2024-25, SZN_YEAR: 2024
+func (s Season) Fmt(sznFmt string) string {
+	if sznFmt == SZN_YEAR {
+		return s.Yr()
 	}
+	return s.String()
+}
 
-	// beginning of year through april
-	sl.Szn = crnt[0]
-	sl.WSzn = crnt[0]
+// season_id for a season type, 22024 for the 2024 regular season
+func (s Season) ID(sznType int) int {
+	return sznType*10000 + int(s)
+}
 
-	// may through september
-	if m > 5 && m < 10 {
-		sl.WSzn = crnt[1]
+/*
+every season from from through to inclusive, counts down when from is later
+e.g. SeasonRange(2025, 1970) for the build etl's newest first order
+*/
+func SeasonRange(from, to Season) []Season {
+	step := Season(1)
+	if from > to {
+		step = -1
 	}
-
-	// october through end of year
-	if m > 10 {
-		sl.Szn = crnt[1]
-		sl.WSzn = crnt[1]
+	var szns []Season
+	for s := from; ; s += step {
+		szns = append(szns, s)
+		if s == to {
+			break
+		}
 	}
-
-	// fmt.Printf("NBA Season: %s | WNBA Season: %s\n", sl.Szn, sl.WSzn)
-	return sl
+	return szns
 }
@@ -0,0 +1,134 @@
+package etl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSeason(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Season
+		err  bool
+	}{
+		{"nba span", "2024-25", 2024, false},
+		{"century rollover", "1999-00", 1999, false},
+		{"wnba year", "2025", 2025, false},
+		{"season_id regular season", "22024", 2024, false},
+		{"season_id playoffs", "42025", 2025, false},
+		{"trimmed", " 2024-25 ", 2024, false},
+		{"span end year off by one", "2024-26", 0, true},
+		{"span no century rollover", "1999-100", 0, true},
+		{"span wrong separator", "2024/25", 0, true},
+		{"year not a number", "20x4", 0, true},
+		{"year too short", "202", 0, true},
+		{"season_id bad season type", "72024", 0, true},
+		{"season_id not a number", "2a024", 0, true},
+		{"empty", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSeason(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseSeason(%q) err = %v, want err %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeason(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeasonID(t *testing.T) {
+	tests := []struct {
+		in    int
+		szn   Season
+		sType int
+		err   bool
+	}{
+		{12024, 2024, 1, false},
+		{22024, 2024, 2, false},
+		{42025, 2025, 4, false},
+		{62023, 2023, 6, false},
+		{2024, 0, 0, true},  // no season type
+		{72024, 0, 0, true}, // unknown season type
+		{20999, 0, 0, true}, // 3 digit year
+	}
+	for _, tt := range tests {
+		szn, st, err := ParseSeasonID(tt.in)
+		if (err != nil) != tt.err {
+			t.Fatalf("ParseSeasonID(%d) err = %v, want err %v", tt.in, err, tt.err)
+		}
+		if szn != tt.szn || st != tt.sType {
+			t.Errorf("ParseSeasonID(%d) = %d, %d, want %d, %d",
+				tt.in, szn, st, tt.szn, tt.sType)
+		}
+	}
+}
+
+func TestSeasonFmt(t *testing.T) {
+	tests := []struct {
+		szn  Season
+		fmt  string
+		want string
+	}{
+		{2024, SZN_SPAN, "2024-25"},
+		{2024, SZN_YEAR, "2024"},
+		{1999, SZN_SPAN, "1999-00"},
+		{1999, SZN_YEAR, "1999"},
+		{2009, SZN_SPAN, "2009-10"},
+	}
+	for _, tt := range tests {
+		if got := tt.szn.Fmt(tt.fmt); got != tt.want {
+			t.Errorf("Season(%d).Fmt(%s) = %s, want %s",
+				tt.szn, tt.fmt, got, tt.want)
+		}
+	}
+	if got := Season(2024).String(); got != "2024-25" {
+		t.Errorf("Season(2024).String() = %s, want 2024-25", got)
+	}
+	if got := Season(2024).Yr(); got != "2024" {
+		t.Errorf("Season(2024).Yr() = %s, want 2024", got)
+	}
+}
+
+func TestSeasonID(t *testing.T) {
+	want := map[int]int{
+		1: 12024, 2: 22024, 3: 32024, 4: 42024, 5: 52024, 6: 62024,
+	}
+	for _, st := range SZN_TYPES {
+		got := Season(2024).ID(st.ID)
+		if got != want[st.ID] {
+			t.Errorf("Season(2024).ID(%d) (%s) = %d, want %d",
+				st.ID, st.Code, got, want[st.ID])
+		}
+		// round trip back through ParseSeasonID
+		szn, sType, err := ParseSeasonID(got)
+		if err != nil || szn != 2024 || sType != st.ID {
+			t.Errorf("ParseSeasonID(%d) = %d, %d, %v", got, szn, sType, err)
+		}
+	}
+}
+
+func TestSeasonRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Season
+		want     []Season
+	}{
+		{"up", 2022, 2024, []Season{2022, 2023, 2024}},
+		{"down", 2025, 2022, []Season{2025, 2024, 2023, 2022}},
+		{"single", 2024, 2024, []Season{2024}},
+		{"down across century", 2000, 1998, []Season{2000, 1999, 1998}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SeasonRange(tt.from, tt.to)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SeasonRange(%d, %d) = %v, want %v",
+					tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+calls shotchartdetail for every field goal attempt in a league/season/type
+TeamID & PlayerID 0 returns every player's shots, DateFrom/DateTo narrow it
+to a day for the nightly run
+stats.sp_shot_zone rolls intake.shot up by player, season & zone
+*/
+
+// first season with shot location data
+const SHOT_FIRST_SZN Season = 1996
+
+var SHOT_TBL = SetTbl{
+	Set:     "Shot_Chart_Detail",
+	Tbl:     "intake.shot",
+	PrimKey: "game_id, game_event_id",
+}
+
+func ShotChartReq(league, season, sType, dateFrom, dateTo string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/shotchartdetail",
+	}
+	gr.SetParams([]Pair{
+		{"LeagueID", league},
+		{"Season", season},
+		{"SeasonType", sType},
+		{"TeamID", "0"},
+		{"PlayerID", "0"},
+		{"GameID", ""},
+		{"Outcome", ""},
+		{"Location", ""},
+		{"Month", "0"},
+		{"SeasonSegment", ""},
+		{"DateFrom", dateFrom},
+		{"DateTo", dateTo},
+		{"OpponentTeamID", "0"},
+		{"VsConference", ""},
+		{"VsDivision", ""},
+		{"Position", ""},
+		{"RookieYear", ""},
+		{"GameSegment", ""},
+		{"Period", "0"},
+		{"LastNGames", "0"},
+		{"ContextMeasure", "FGA"},
+		{"PlayerPosition", ""},
+	})
+	return gr
+}
+
+// fetch a shot chart request & load Shot_Chart_Detail into intake.shot
+func ShotETL(ctx context.Context, cnf *Conf, r GetReq) error {
+	e := errd.InitErr()
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s", r.Endpoint)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp, []SetTbl{SHOT_TBL})
+}
+
+/*
+shots for every league in lgs & season type for a single season
+seasons before SHOT_FIRST_SZN or the league's first are skipped, the api has
+no locations for them
+*/
+func ShotSeasonETL(
+	ctx context.Context, cnf *Conf, lgs []League, szn string,
+) error {
+	e := errd.InitErr()
+	for _, lg := range lgs {
+		lSzn, sznY, started, err := lg.SznOf(szn)
+		if err != nil {
+			e.Msg = fmt.Sprintf("error parsing season %s", szn)
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+		if !started || sznY < SHOT_FIRST_SZN {
+			cnf.L.WriteLog(fmt.Sprintf(
+				"skipping %s shot charts for %s - no shot data before %s",
+				lg.Abbr, szn, lg.Szn(max(lg.First, SHOT_FIRST_SZN))))
+			continue
+		}
+		for _, s := range LgSznTypes(cnf.sznTypes(), lg.ID, true) {
+			r := ShotChartReq(lg.ID, lSzn, s, "", "")
+			cnf.L.WriteLog(fmt.Sprintf(
+				"attempting to fetch %s: LG=%s, SZN=%s %s",
+				r.Endpoint, lg.Code, lSzn, s))
+			if err := ShotETL(ctx, cnf, r); err != nil {
+				e.Msg = fmt.Sprintf(
+					"error during shot chart ETL. LG=%s, SZN=%s %s",
+					lg.Code, lSzn, s)
+				cnf.L.WriteLog(e.Msg)
+				return e.BuildErr(err)
+			}
+			cnf.L.WriteLog(fmt.Sprintf(
+				"finished with shots LG=%s, SZN=%s %s", lg.Code, lSzn, s))
+		}
+	}
+	return nil
+}
+
+// nightly shot chart fetch for every default league, yesterday as DateFrom/DateTo
+func ShotDailyETL(ctx context.Context, cnf *Conf) error {
+	e := errd.InitErr()
+	yesterday := Yesterday(cnf.Now())
+
+	for _, lg := range DefaultLeagues() {
+		szn := cnf.CrntSzn(lg)
+		for _, s := range LgSznTypes(cnf.sznTypes(), lg.ID, true) {
+			r := ShotChartReq(lg.ID, szn, s, yesterday, yesterday)
+			cnf.L.WriteLog(fmt.Sprintf(
+				"attempting to fetch %s: LG=%s, SZN=%s %s, DATE=%s",
+				r.Endpoint, lg.Code, szn, s, yesterday))
+			if err := ShotETL(ctx, cnf, r); err != nil {
+				e.Msg = fmt.Sprintf(
+					"error during daily shot chart ETL. LG=%s, SZN=%s %s, DATE=%s",
+					lg.Code, szn, s, yesterday)
+				cnf.L.WriteLog(e.Msg)
+				return e.BuildErr(err)
+			}
+		}
+		cnf.L.WriteLog(fmt.Sprintf(
+			"finished with shots LG=%s, SZN=%s, DATE=%s",
+			lg.Code, szn, yesterday))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+calls leaguestandingsv3 for conference/division rank, games back, streaks &
+clinch flags, stored as a dated snapshot per league & season in
+intake.standings so the race can be followed day by day
+*/
+
+func StandingsReq(league, season, sType string) GetReq {
+	var gr = GetReq{
+		Host:     HOST,
+		Headers:  HDRS,
+		Endpoint: "/stats/leaguestandingsv3",
+	}
+	gr.SetParams([]Pair{
+		{"LeagueID", league},
+		{"Season", season},
+		{"SeasonType", sType},
+	})
+	return gr
+}
+
+/*
+Standings set with SNAP_DATE added to every row, one snapshot per day
+the columns differ a bit by league & season (e.g. wnba has no divisions vs
+columns), only the ones shared with the table are loaded
+*/
+func StandingsTbl(snap time.Time) SetTbl {
+	return SetTbl{
+		Set:     "Standings",
+		Tbl:     "intake.standings",
+		PrimKey: "snap_date, leagueid, seasonid, teamid",
+		Drift:   DRIFT_SHARED,
+		Consts:  []Pair{{"SNAP_DATE", snap.Format("2006-01-02")}},
+	}
+}
+
+// fetch & load today's standings snapshot for a league & season
+func StandingsETL(ctx context.Context, cnf *Conf, league, season string) error {
+	e := errd.InitErr()
+	r := StandingsReq(league, season, "Regular Season")
+	cnf.L.WriteLog(fmt.Sprintf("attempting to fetch %s: LG=%s, SZN=%s",
+		r.Endpoint, league, season))
+	resp, err := RequestResp(ctx, cnf, r)
+	if err != nil {
+		e.Msg = fmt.Sprintf("error getting response for %s: LG=%s, SZN=%s",
+			r.Endpoint, league, season)
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return LoadSets(ctx, cnf, resp,
+		[]SetTbl{StandingsTbl(GameDay(cnf.Now()))})
+}
+
+// nightly standings snapshot for every default league's current season
+func StandingsDailyETL(ctx context.Context, cnf *Conf) error {
+	e := errd.InitErr()
+	for _, lg := range DefaultLeagues() {
+		if err := StandingsETL(ctx, cnf, lg.ID, cnf.CrntSzn(lg)); err != nil {
+			e.Msg = fmt.Sprintf(
+				"error during daily standings ETL. LG=%s, SZN=%s",
+				lg.Code, cnf.CrntSzn(lg))
+			cnf.L.WriteLog(e.Msg)
+			return e.BuildErr(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package etl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+season types the api can return, ID matches lg.szn_type.sznt_id & the first
+digit of a game log's season_id, e.g. 52024 for 2024-25 play-in
+the game log, shot chart etc loops use cnf.sznTypes(), picked with -stypes
+*/
+type SznType struct {
+	ID    int      // lg.szn_type.sznt_id
+	Code  string   // -stypes value
+	API   string   // SeasonType param value
+	Lgs   []string // league ids with this season type
+	Shots bool     // shotchartdetail accepts it
+	Dflt  bool     // fetched when -stypes isn't passed
+}
+
+var SZN_TYPES = []SznType{
+	{
+		ID: 1, Code: "pre", API: "Pre Season",
+		Lgs: []string{"00", "10"}, Shots: true,
+	},
+	{
+		ID: 2, Code: "reg", API: "Regular Season",
+		Lgs: []string{"00", "10", "20"}, Shots: true, Dflt: true,
+	},
+	{
+		ID: 3, Code: "allstar", API: "All Star",
+		Lgs: []string{"00", "10"}, Shots: true,
+	},
+	{
+		ID: 4, Code: "playoffs", API: "Playoffs",
+		Lgs: []string{"00", "10", "20"}, Shots: true, Dflt: true,
+	},
+	{
+		ID: 5, Code: "playin", API: "PlayIn",
+		Lgs: []string{"00"}, Dflt: true, // no wnba play-in
+	},
+	{ // nba cup & wnba commissioner's cup championship games
+		ID: 6, Code: "cup", API: "IST",
+		Lgs: []string{"00", "10"}, Dflt: true,
+	},
+}
+
+// season types fetched when -stypes isn't passed
+func DefaultSznTypes() []SznType {
+	var sts []SznType
+	for _, st := range SZN_TYPES {
+		if st.Dflt {
+			sts = append(sts, st)
+		}
+	}
+	return sts
+}
+
+/*
+comma separated -stypes codes to season types, e.g. "reg,playoffs,playin"
+"all" returns every type, empty returns the defaults
+*/
+func ParseSznTypes(s string) ([]SznType, error) {
+	switch s {
+	case "":
+		return DefaultSznTypes(), nil
+	case "all":
+		return SZN_TYPES, nil
+	}
+	var sts []SznType
+	for c := range strings.SplitSeq(s, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		i := slices.IndexFunc(SZN_TYPES, func(st SznType) bool {
+			return st.Code == c
+		})
+		if i < 0 {
+			var codes []string
+			for _, st := range SZN_TYPES {
+				codes = append(codes, st.Code)
+			}
+			return nil, fmt.Errorf(
+				"invalid season type '%s': must be all or one or more of %s",
+				c, strings.Join(codes, ", "))
+		}
+		if !slices.ContainsFunc(sts, func(st SznType) bool {
+			return st.Code == c
+		}) {
+			sts = append(sts, SZN_TYPES[i])
+		}
+	}
+	return sts, nil
+}
+
+// the season types in sts that a league has, shots limits to shot chart ones
+func LgSznTypes(sts []SznType, lg string, shots bool) []string {
+	var out []string
+	for _, st := range sts {
+		if slices.Contains(st.Lgs, lg) && (st.Shots || !shots) {
+			out = append(out, st.API)
+		}
+	}
+	return out
+}
+
+// cnf.STypes, or the defaults if they weren't set
+func (cnf *Conf) sznTypes() []SznType {
+	if len(cnf.STypes) == 0 {
+		return DefaultSznTypes()
+	}
+	return cnf.STypes
+}
@@ -0,0 +1,247 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jdetok/golib/errd"
+)
+
+/*
+roster moves found by comparing the current commonallplayers response with
+the team stored in intake.player/intake.wplayer before it's updated
+  - TRADE: stored team & new team are both set & different
+  - SIGNING: no stored team (or a player new to the table) & a new team
+  - WAIVER: a stored team & no new team, or a stored player on a team who is
+    no longer in the current players response (released/waived)
+
+effective date is the date the change showed up in the api, the nightly
+run's yesterday, the api doesn't say when the move happened
+*/
+
+const (
+	TRANS_TRADE   = "TRADE"
+	TRANS_SIGNING = "SIGNING"
+	TRANS_WAIVER  = "WAIVER"
+)
+
+/*
+more players dropped from the response than this share of the stored players
+with a team (& more than TRANS_MAX_DROP_MIN) is treated as a bad response, e.g.
+a season rollover or a partial list, instead of a night of waivers
+*/
+const (
+	TRANS_MAX_DROP     = 0.1
+	TRANS_MAX_DROP_MIN = 10
+)
+
+var ErrMassDrop = errors.New("too many stored players missing from the response")
+
+var TRANS_TBL = SetTbl{
+	Set:     "Transactions",
+	Tbl:     "intake.transaction",
+	PrimKey: "player_id, effective_date, to_team_id",
+}
+
+type Transaction struct {
+	PlayerID  int64
+	Player    string
+	Lg        string
+	Type      string
+	FromTeam  int64
+	FromAbbr  string
+	ToTeam    int64
+	ToAbbr    string
+	Effective time.Time
+}
+
+func (t Transaction) String() string {
+	return fmt.Sprintf("%s %s: %s (%d) %s (%d) -> %s (%d)",
+		t.Effective.Format("2006-01-02"), t.Type, t.Player, t.PlayerID,
+		t.FromAbbr, t.FromTeam, t.ToAbbr, t.ToTeam)
+}
+
+// a player's team as stored before this run
+type storedTeam struct {
+	TeamID int64
+	Abbr   string
+	Name   string // display_first_last, for players missing from the response
+}
+
+// team id, abbreviation & name of every player in a player table
+func StoredTeams(
+	ctx context.Context, cnf *Conf, tbl string,
+) (map[int64]storedTeam, error) {
+	rows, err := cnf.DB.QueryContext(ctx, fmt.Sprintf(`
+		select person_id, coalesce(team_id, 0), coalesce(team_abbreviation, ''),
+			coalesce(display_first_last, '')
+		from %s`, tbl))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	st := make(map[int64]storedTeam)
+	for rows.Next() {
+		var pid int64
+		var t storedTeam
+		if err := rows.Scan(&pid, &t.TeamID, &t.Abbr, &t.Name); err != nil {
+			return nil, err
+		}
+		st[pid] = t
+	}
+	return st, rows.Err()
+}
+
+/*
+compare the response's players to the stored teams, an empty stored map (first
+run on a new database) returns nothing instead of a signing for every player
+stored players on a team who aren't in prs are waivers, an empty prs returns
+nothing instead of waiving every player, too many of them returns ErrMassDrop
+*/
+func DetectTransactions(
+	lg string, stored map[int64]storedTeam, prs []PlayerRow, eff time.Time,
+) ([]Transaction, error) {
+	if len(stored) == 0 || len(prs) == 0 {
+		return nil, nil
+	}
+	var trs []Transaction
+	inResp := make(map[int64]bool, len(prs))
+	for _, p := range prs {
+		inResp[p.PersonID] = true
+		old := stored[p.PersonID]
+		if old.TeamID == p.TeamID {
+			continue
+		}
+		t := Transaction{
+			PlayerID:  p.PersonID,
+			Player:    p.FirstLast,
+			Lg:        lg,
+			FromTeam:  old.TeamID,
+			FromAbbr:  old.Abbr,
+			ToTeam:    p.TeamID,
+			Effective: eff,
+		}
+		if p.TeamAbbr != nil {
+			t.ToAbbr = *p.TeamAbbr
+		}
+		switch {
+		case old.TeamID > 0 && p.TeamID > 0:
+			t.Type = TRANS_TRADE
+		case p.TeamID > 0:
+			t.Type = TRANS_SIGNING
+		default:
+			t.Type = TRANS_WAIVER
+		}
+		trs = append(trs, t)
+	}
+
+	// dropped from the current players, e.g. released & unsigned
+	var dropped []int64
+	var onTeam int
+	for pid, old := range stored {
+		if old.TeamID == 0 {
+			continue
+		}
+		onTeam++
+		if !inResp[pid] {
+			dropped = append(dropped, pid)
+		}
+	}
+	if len(dropped) > TRANS_MAX_DROP_MIN &&
+		float64(len(dropped)) > TRANS_MAX_DROP*float64(onTeam) {
+		return nil, fmt.Errorf("%w: %d of %d %s players on a team",
+			ErrMassDrop, len(dropped), onTeam, lg)
+	}
+	slices.Sort(dropped)
+	for _, pid := range dropped {
+		old := stored[pid]
+		trs = append(trs, Transaction{
+			PlayerID:  pid,
+			Player:    old.Name,
+			Lg:        lg,
+			Type:      TRANS_WAIVER,
+			FromTeam:  old.TeamID,
+			FromAbbr:  old.Abbr,
+			Effective: eff,
+		})
+	}
+	return trs, nil
+}
+
+/*
+clear the team of every waived player in tbl, players dropped from the current
+players response aren't in the upsert so their stored team is stale otherwise
+*/
+func ClearTeams(
+	ctx context.Context, cnf *Conf, tbl string, trs []Transaction,
+) error {
+	var ph []string
+	var args []any
+	for _, t := range trs {
+		if t.Type == TRANS_WAIVER {
+			args = append(args, t.PlayerID)
+			ph = append(ph, fmt.Sprintf("$%d", len(args)))
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	res, err := cnf.DB.ExecContext(ctx, fmt.Sprintf(`
+		update %s set team_id = 0, rosterstatus = false, team_city = null,
+			team_name = null, team_abbreviation = null, team_code = null,
+			team_slug = null
+		where person_id in (%s)`, tbl, strings.Join(ph, ", ")), args...)
+	if err != nil {
+		return err
+	}
+	ra, _ := res.RowsAffected()
+	cnf.RowCnt += ra
+	cnf.L.WriteLog(fmt.Sprintf("cleared team for %d waived players in %s",
+		ra, tbl))
+	return nil
+}
+
+// transactions as a result set, headers match intake.transaction
+func TransSet(trs []Transaction) ResultSet {
+	rs := ResultSet{
+		Name: TRANS_TBL.Set,
+		Headers: []string{
+			"PLAYER_ID", "PLAYER", "LG_ID", "TRANS_TYPE", "FROM_TEAM_ID",
+			"FROM_TEAM_ABBR", "TO_TEAM_ID", "TO_TEAM_ABBR", "EFFECTIVE_DATE",
+		},
+	}
+	for _, t := range trs {
+		rs.RowSet = append(rs.RowSet, []any{
+			t.PlayerID, t.Player, t.Lg, t.Type, t.FromTeam,
+			nullIfEmpty(t.FromAbbr), t.ToTeam, nullIfEmpty(t.ToAbbr),
+			t.Effective.Format("2006-01-02"),
+		})
+	}
+	return rs
+}
+
+/*
+insert trs into intake.transaction, each is logged & added to cnf.Events for
+the run summary
+*/
+func LoadTransactions(ctx context.Context, cnf *Conf, trs []Transaction) error {
+	e := errd.InitErr()
+	if len(trs) == 0 {
+		return nil
+	}
+	for _, t := range trs {
+		cnf.L.WriteLog(fmt.Sprintf("transaction detected: %v", t))
+		cnf.Events = append(cnf.Events, t.String())
+	}
+	if err := LoadSet(ctx, cnf, TransSet(trs), TRANS_TBL); err != nil {
+		e.Msg = fmt.Sprintf("error inserting %d transactions", len(trs))
+		cnf.L.WriteLog(e.Msg)
+		return e.BuildErr(err)
+	}
+	return nil
+}
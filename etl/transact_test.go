@@ -0,0 +1,126 @@
+package etl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// a current players row on team tid (0 for no team)
+func plrRow(pid int64, name string, tid int64, abbr string) PlayerRow {
+	p := PlayerRow{PersonID: pid, FirstLast: name, TeamID: tid}
+	if abbr != "" {
+		p.TeamAbbr = ptr(abbr)
+	}
+	return p
+}
+
+func TestDetectTransactions(t *testing.T) {
+	eff := time.Date(2025, 1, 15, 0, 0, 0, 0, ET)
+	stored := map[int64]storedTeam{
+		1: {1610612747, "LAL", "Ava Stay"},
+		2: {1610612747, "LAL", "Ben Trade"},
+		3: {0, "", "Cal Sign"},
+		4: {1610612748, "MIA", "Dan Cut"},
+		5: {1610612752, "NYK", "Eli Gone"},
+		6: {0, "", "Finn Retired"},
+	}
+	prs := []PlayerRow{
+		plrRow(1, "Ava Stay", 1610612747, "LAL"),
+		plrRow(2, "Ben Trade", 1610612744, "GSW"),
+		plrRow(3, "Cal Sign", 1610612744, "GSW"),
+		plrRow(4, "Dan Cut", 0, ""),
+		plrRow(7, "Gus New", 1610612743, "DEN"),
+	}
+
+	tests := []struct {
+		name   string
+		stored map[int64]storedTeam
+		prs    []PlayerRow
+		want   []string
+	}{
+		{"moves", stored, prs, []string{
+			"2025-01-15 TRADE: Ben Trade (2) LAL (1610612747) -> GSW (1610612744)",
+			"2025-01-15 SIGNING: Cal Sign (3)  (0) -> GSW (1610612744)",
+			"2025-01-15 WAIVER: Dan Cut (4) MIA (1610612748) ->  (0)",
+			"2025-01-15 SIGNING: Gus New (7)  (0) -> DEN (1610612743)",
+			"2025-01-15 WAIVER: Eli Gone (5) NYK (1610612752) ->  (0)",
+		}},
+		{"no changes", map[int64]storedTeam{
+			1: {1610612747, "LAL", "Ava Stay"},
+			6: {0, "", "Finn Retired"},
+		}, prs[:1], nil},
+		{"nothing stored", nil, prs, nil},
+		{"empty response", stored, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trs, err := DetectTransactions("00", tt.stored, tt.prs, eff)
+			if err != nil {
+				t.Fatalf("DetectTransactions: %v", err)
+			}
+			var got []string
+			for _, tr := range trs {
+				got = append(got, tr.String())
+				if tr.Lg != "00" {
+					t.Errorf("%v league = %q, want 00", tr, tr.Lg)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("transactions = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// dropped players past the threshold abort instead of waiving everyone
+func TestDetectTransactionsMassDrop(t *testing.T) {
+	eff := time.Date(2025, 10, 1, 0, 0, 0, 0, ET)
+	for _, tt := range []struct {
+		onTeam, dropped int
+		abort           bool
+	}{
+		{200, 10, false}, // under the minimum count
+		{200, 20, false}, // exactly 10%
+		{200, 21, true},
+		{50, 11, true}, // over the minimum count & 10%
+		{400, 30, false},
+	} {
+		t.Run(fmt.Sprintf("%d of %d", tt.dropped, tt.onTeam), func(t *testing.T) {
+			stored := make(map[int64]storedTeam, tt.onTeam)
+			var prs []PlayerRow
+			for i := range tt.onTeam {
+				pid := int64(i + 1)
+				stored[pid] = storedTeam{1610612747, "LAL", fmt.Sprint("p", pid)}
+				if i >= tt.dropped {
+					prs = append(prs,
+						plrRow(pid, fmt.Sprint("p", pid), 1610612747, "LAL"))
+				}
+			}
+			// a stored player with no team doesn't count towards the share
+			stored[0] = storedTeam{Name: "free agent"}
+
+			trs, err := DetectTransactions("10", stored, prs, eff)
+			if tt.abort {
+				if !errors.Is(err, ErrMassDrop) || trs != nil {
+					t.Fatalf("DetectTransactions = %d, %v, want ErrMassDrop",
+						len(trs), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectTransactions: %v", err)
+			}
+			if len(trs) != tt.dropped {
+				t.Fatalf("%d transactions, want %d waivers", len(trs), tt.dropped)
+			}
+			for _, tr := range trs {
+				if tr.Type != TRANS_WAIVER || tr.ToTeam != 0 {
+					t.Errorf("dropped player isn't waived: %v", tr)
+				}
+			}
+		})
+	}
+}
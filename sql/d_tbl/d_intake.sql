@@ -43,6 +43,30 @@ create table intake.wplayer (
 
 create index idx_inwpl_team on intake.wplayer(team_id);
 
+-- commonallplayers for LeagueID 20, rosterstatus & is_nba_assigned are the
+-- api's 0/1, nba_assigned_team_id the nba team a player is assigned from
+create table intake.gplayer (
+    person_id bigint primary key,
+    display_last_comma_first varchar(255),
+    display_first_last varchar(255),
+    rosterstatus int,
+    from_year varchar(4),
+    to_year varchar(4),
+    playercode varchar(255),
+    player_slug varchar(255),
+    team_id bigint,
+    team_city varchar(255),
+    team_name varchar(255),
+    team_abbreviation varchar(10),
+    team_code varchar(255),
+    team_slug varchar(255),
+    is_nba_assigned int,
+    nba_assigned_team_id bigint,
+    games_played_flag varchar(1)
+);
+
+create index idx_ingpl_team on intake.gplayer(team_id);
+
 create table intake.gm_player (
     season_id int not null,
     player_id bigint not null,
@@ -119,4 +143,596 @@ create table intake.gm_team (
 
 create index idx_ingmtm_team_abbr on intake.gm_team(team_abbreviation);
 create index idx_ingmtm_szn on intake.gm_team(season_id);
-create index idx_ingmtm_gdate on intake.gm_team(game_date);
\ No newline at end of file
+create index idx_ingmtm_gdate on intake.gm_team(game_date);
+-- boxscoresummaryv2 result sets, one row per game or per game/team
+create table intake.gm_summary (
+    game_date_est timestamp,
+    game_sequence int,
+    game_id bigint primary key,
+    game_status_id int,
+    game_status_text varchar(50),
+    gamecode varchar(50),
+    home_team_id bigint,
+    visitor_team_id bigint,
+    season varchar(4),
+    live_period int,
+    live_pc_time varchar(20),
+    natl_tv_broadcaster_abbreviation varchar(20),
+    live_period_time_bcast varchar(50),
+    wh_status int
+);
+
+create index idx_ingmsum_gdate on intake.gm_summary(game_date_est);
+
+create table intake.gm_linescore (
+    game_date_est timestamp,
+    game_sequence int,
+    game_id bigint not null,
+    team_id bigint not null,
+    team_abbreviation varchar(3),
+    team_city_name varchar(255),
+    team_nickname varchar(255),
+    team_wins_losses varchar(10),
+    pts_qtr1 int,
+    pts_qtr2 int,
+    pts_qtr3 int,
+    pts_qtr4 int,
+    pts_ot1 int,
+    pts_ot2 int,
+    pts_ot3 int,
+    pts_ot4 int,
+    pts_ot5 int,
+    pts_ot6 int,
+    pts_ot7 int,
+    pts_ot8 int,
+    pts_ot9 int,
+    pts_ot10 int,
+    pts int,
+    primary key (game_id, team_id)
+);
+
+create table intake.gm_info (
+    game_id bigint primary key,
+    game_date varchar(50),
+    attendance int,
+    game_time varchar(10)
+);
+
+create table intake.gm_other (
+    game_id bigint not null,
+    league_id varchar(2),
+    team_id bigint not null,
+    team_abbreviation varchar(3),
+    team_city varchar(255),
+    pts_paint int,
+    pts_2nd_chance int,
+    pts_fb int,
+    largest_lead int,
+    lead_changes int,
+    times_tied int,
+    team_turnovers int,
+    total_turnovers int,
+    team_rebounds int,
+    pts_off_to int,
+    primary key (game_id, team_id)
+);
+
+create table intake.gm_official (
+    game_id bigint not null,
+    official_id bigint not null,
+    first_name varchar(255),
+    last_name varchar(255),
+    jersey_num varchar(10),
+    primary key (game_id, official_id)
+);
+
+create index idx_ingmoff_official on intake.gm_official(official_id);
+
+create table intake.gm_inactive (
+    game_id bigint not null,
+    player_id bigint not null,
+    first_name varchar(255),
+    last_name varchar(255),
+    jersey_num varchar(10),
+    team_id bigint,
+    team_city varchar(255),
+    team_name varchar(255),
+    team_abbreviation varchar(3),
+    primary key (game_id, player_id)
+);
+
+create index idx_ingminact_team on intake.gm_inactive(team_id);
+
+-- playbyplayv3 actions, one row per event
+create table intake.pbp (
+    game_id bigint not null,
+    action_number int not null,
+    clock varchar(20),
+    period int,
+    team_id bigint,
+    team_tricode varchar(3),
+    person_id bigint,
+    player_name varchar(255),
+    player_name_i varchar(255),
+    x_legacy int,
+    y_legacy int,
+    shot_distance int,
+    shot_result varchar(10),
+    is_field_goal int,
+    score_home int,
+    score_away int,
+    points_total int,
+    location varchar(1),
+    description varchar(255),
+    action_type varchar(50),
+    sub_type varchar(50),
+    video_available int,
+    shot_value int,
+    action_id int,
+    primary key (game_id, action_number)
+);
+
+create index idx_inpbp_person on intake.pbp(person_id);
+create index idx_inpbp_team on intake.pbp(team_id);
+create index idx_inpbp_atype on intake.pbp(action_type);
+
+-- one row per game playbyplayv3 was called for, games with 0 actions never
+-- get intake.pbp rows, this is what stops them being fetched again
+create table intake.pbp_game (
+    game_id bigint primary key,
+    actions int not null,
+    fetched_at timestamptz not null default now()
+);
+
+-- games loaded before intake.pbp_game existed
+insert into intake.pbp_game (game_id, actions)
+select game_id, count(*) from intake.pbp group by game_id
+on conflict (game_id) do nothing;
+
+-- shotchartdetail Shot_Chart_Detail, one row per field goal attempt
+create table intake.shot (
+    grid_type varchar(50),
+    game_id bigint not null,
+    game_event_id int not null,
+    player_id bigint,
+    player_name varchar(255),
+    team_id bigint,
+    team_name varchar(255),
+    period int,
+    minutes_remaining int,
+    seconds_remaining int,
+    event_type varchar(50),
+    action_type varchar(255),
+    shot_type varchar(50),
+    shot_zone_basic varchar(50),
+    shot_zone_area varchar(50),
+    shot_zone_range varchar(50),
+    shot_distance int,
+    loc_x int,
+    loc_y int,
+    shot_attempted_flag int,
+    shot_made_flag int,
+    game_date date,
+    htm varchar(3),
+    vtm varchar(3),
+    primary key (game_id, game_event_id)
+);
+
+create index idx_inshot_player on intake.shot(player_id);
+create index idx_inshot_team on intake.shot(team_id);
+create index idx_inshot_gdate on intake.shot(game_date);
+
+-- scheduleleaguev2 games, upserted nightly as statuses & scores change
+create table intake.schedule (
+    game_id bigint primary key,
+    lg_id varchar(2) not null,
+    season varchar(10),
+    game_date date,
+    game_code varchar(50),
+    game_status int,
+    game_status_text varchar(50),
+    game_label varchar(255),
+    arena_name varchar(255),
+    home_team_id bigint,
+    home_team_tricode varchar(3),
+    home_score int,
+    away_team_id bigint,
+    away_team_tricode varchar(3),
+    away_score int
+);
+
+create index idx_insched_gdate on intake.schedule(game_date);
+create index idx_insched_lg on intake.schedule(lg_id);
+
+-- commonplayerinfo CommonPlayerInfo, upserted, updated_at set on every load
+-- updated_at is first, the etl adds it ahead of the api columns
+create table intake.player_info (
+    updated_at timestamptz not null default now(),
+    person_id bigint primary key,
+    first_name varchar(255),
+    last_name varchar(255),
+    display_first_last varchar(255),
+    display_last_comma_first varchar(255),
+    display_fi_last varchar(255),
+    player_slug varchar(255),
+    birthdate timestamp,
+    school varchar(255),
+    country varchar(255),
+    last_affiliation varchar(255),
+    height varchar(10),
+    weight varchar(10),
+    season_exp int,
+    jersey varchar(10),
+    position varchar(50),
+    rosterstatus varchar(10),
+    games_played_current_season_flag varchar(1),
+    team_id bigint,
+    team_name varchar(255),
+    team_abbreviation varchar(10),
+    team_code varchar(255),
+    team_city varchar(255),
+    playercode varchar(255),
+    from_year int,
+    to_year int,
+    dleague_flag varchar(1),
+    nba_flag varchar(1),
+    games_played_flag varchar(1),
+    draft_year varchar(20),
+    draft_round varchar(20),
+    draft_number varchar(20),
+    greatest_75_flag varchar(1)
+);
+
+create index idx_inplinfo_team on intake.player_info(team_id);
+create index idx_inplinfo_updated on intake.player_info(updated_at);
+
+-- commonteamroster CommonTeamRoster, one snapshot per team per day
+-- snap_date is first, the etl adds it ahead of the api columns
+create table intake.roster (
+    snap_date date not null,
+    teamid bigint not null,
+    season varchar(10),
+    leagueid varchar(2),
+    player varchar(255),
+    nickname varchar(255),
+    player_slug varchar(255),
+    num varchar(10),
+    position varchar(10),
+    height varchar(10),
+    weight varchar(10),
+    birth_date varchar(20),
+    age numeric(4, 1),
+    exp varchar(10),
+    school varchar(255),
+    player_id bigint not null,
+    how_acquired varchar(255),
+    primary key (snap_date, teamid, player_id)
+);
+
+create index idx_inrost_player on intake.roster(player_id);
+
+-- commonteamroster Coaches, same snapshots as intake.roster
+create table intake.coach (
+    snap_date date not null,
+    team_id bigint not null,
+    season varchar(10),
+    coach_id varchar(50) not null,
+    first_name varchar(255),
+    last_name varchar(255),
+    coach_name varchar(255),
+    is_assistant numeric(4, 1),
+    coach_type varchar(255),
+    sort_sequence int,
+    sub_sort_sequence int,
+    primary key (snap_date, team_id, coach_id)
+);
+
+-- roster moves detected by comparing commonallplayers to intake.[w]player
+create table intake.transaction (
+    player_id bigint not null,
+    player varchar(255),
+    lg_id varchar(2),
+    trans_type varchar(10) not null,
+    from_team_id bigint,
+    from_team_abbr varchar(10),
+    to_team_id bigint not null,
+    to_team_abbr varchar(10),
+    effective_date date not null,
+    primary key (player_id, effective_date, to_team_id)
+);
+
+create index idx_intrans_date on intake.transaction(effective_date);
+create index idx_intrans_type on intake.transaction(trans_type);
+
+-- boxscoreadvancedv2 PlayerStats
+create table intake.box_adv_plr (
+    game_id bigint not null,
+    team_id bigint,
+    team_abbreviation varchar(10),
+    team_city varchar(255),
+    player_id bigint not null,
+    player_name varchar(255),
+    nickname varchar(255),
+    start_position varchar(5),
+    comment varchar(255),
+    min varchar(20),
+    e_off_rating numeric(7, 3),
+    off_rating numeric(7, 3),
+    e_def_rating numeric(7, 3),
+    def_rating numeric(7, 3),
+    e_net_rating numeric(7, 3),
+    net_rating numeric(7, 3),
+    ast_pct numeric(7, 3),
+    ast_tov numeric(7, 3),
+    ast_ratio numeric(7, 3),
+    oreb_pct numeric(7, 3),
+    dreb_pct numeric(7, 3),
+    reb_pct numeric(7, 3),
+    tm_tov_pct numeric(7, 3),
+    efg_pct numeric(7, 3),
+    ts_pct numeric(7, 3),
+    usg_pct numeric(7, 3),
+    e_usg_pct numeric(7, 3),
+    e_pace numeric(7, 3),
+    pace numeric(7, 3),
+    pace_per40 numeric(7, 3),
+    poss int,
+    pie numeric(7, 3),
+    primary key (game_id, player_id)
+);
+
+-- boxscoreadvancedv2 TeamStats
+create table intake.box_adv_tm (
+    game_id bigint not null,
+    team_id bigint not null,
+    team_name varchar(255),
+    team_abbreviation varchar(10),
+    team_city varchar(255),
+    min varchar(20),
+    e_off_rating numeric(7, 3),
+    off_rating numeric(7, 3),
+    e_def_rating numeric(7, 3),
+    def_rating numeric(7, 3),
+    e_net_rating numeric(7, 3),
+    net_rating numeric(7, 3),
+    ast_pct numeric(7, 3),
+    ast_tov numeric(7, 3),
+    ast_ratio numeric(7, 3),
+    oreb_pct numeric(7, 3),
+    dreb_pct numeric(7, 3),
+    reb_pct numeric(7, 3),
+    e_tm_tov_pct numeric(7, 3),
+    tm_tov_pct numeric(7, 3),
+    efg_pct numeric(7, 3),
+    ts_pct numeric(7, 3),
+    usg_pct numeric(7, 3),
+    e_usg_pct numeric(7, 3),
+    e_pace numeric(7, 3),
+    pace numeric(7, 3),
+    pace_per40 numeric(7, 3),
+    poss int,
+    pie numeric(7, 3),
+    primary key (game_id, team_id)
+);
+
+-- boxscorefourfactorsv2 sqlPlayersFourFactors
+create table intake.box_ff_plr (
+    game_id bigint not null,
+    team_id bigint,
+    team_abbreviation varchar(10),
+    team_city varchar(255),
+    player_id bigint not null,
+    player_name varchar(255),
+    nickname varchar(255),
+    start_position varchar(5),
+    comment varchar(255),
+    min varchar(20),
+    efg_pct numeric(7, 3),
+    fta_rate numeric(7, 3),
+    tm_tov_pct numeric(7, 3),
+    oreb_pct numeric(7, 3),
+    opp_efg_pct numeric(7, 3),
+    opp_fta_rate numeric(7, 3),
+    opp_tov_pct numeric(7, 3),
+    opp_oreb_pct numeric(7, 3),
+    primary key (game_id, player_id)
+);
+
+-- boxscorefourfactorsv2 sqlTeamsFourFactors
+create table intake.box_ff_tm (
+    game_id bigint not null,
+    team_id bigint not null,
+    team_name varchar(255),
+    team_abbreviation varchar(10),
+    team_city varchar(255),
+    min varchar(20),
+    efg_pct numeric(7, 3),
+    fta_rate numeric(7, 3),
+    tm_tov_pct numeric(7, 3),
+    oreb_pct numeric(7, 3),
+    opp_efg_pct numeric(7, 3),
+    opp_fta_rate numeric(7, 3),
+    opp_tov_pct numeric(7, 3),
+    opp_oreb_pct numeric(7, 3),
+    primary key (game_id, team_id)
+);
+
+-- boxscoreusagev2 sqlPlayersUsage
+create table intake.box_usg_plr (
+    game_id bigint not null,
+    team_id bigint,
+    team_abbreviation varchar(10),
+    team_city varchar(255),
+    player_id bigint not null,
+    player_name varchar(255),
+    nickname varchar(255),
+    start_position varchar(5),
+    comment varchar(255),
+    min varchar(20),
+    usg_pct numeric(7, 3),
+    pct_fgm numeric(7, 3),
+    pct_fga numeric(7, 3),
+    pct_fg3m numeric(7, 3),
+    pct_fg3a numeric(7, 3),
+    pct_ftm numeric(7, 3),
+    pct_fta numeric(7, 3),
+    pct_oreb numeric(7, 3),
+    pct_dreb numeric(7, 3),
+    pct_reb numeric(7, 3),
+    pct_ast numeric(7, 3),
+    pct_tov numeric(7, 3),
+    pct_stl numeric(7, 3),
+    pct_blk numeric(7, 3),
+    pct_blka numeric(7, 3),
+    pct_pf numeric(7, 3),
+    pct_pfd numeric(7, 3),
+    pct_pts numeric(7, 3),
+    primary key (game_id, player_id)
+);
+
+-- boxscoreusagev2 sqlTeamsUsage
+create table intake.box_usg_tm (
+    game_id bigint not null,
+    team_id bigint not null,
+    team_name varchar(255),
+    team_abbreviation varchar(10),
+    team_city varchar(255),
+    min varchar(20),
+    usg_pct numeric(7, 3),
+    pct_fgm numeric(7, 3),
+    pct_fga numeric(7, 3),
+    pct_fg3m numeric(7, 3),
+    pct_fg3a numeric(7, 3),
+    pct_ftm numeric(7, 3),
+    pct_fta numeric(7, 3),
+    pct_oreb numeric(7, 3),
+    pct_dreb numeric(7, 3),
+    pct_reb numeric(7, 3),
+    pct_ast numeric(7, 3),
+    pct_tov numeric(7, 3),
+    pct_stl numeric(7, 3),
+    pct_blk numeric(7, 3),
+    pct_blka numeric(7, 3),
+    pct_pf numeric(7, 3),
+    pct_pfd numeric(7, 3),
+    pct_pts numeric(7, 3),
+    primary key (game_id, team_id)
+);
+
+create index idx_inboxadvp_player on intake.box_adv_plr(player_id);
+create index idx_inboxffp_player on intake.box_ff_plr(player_id);
+create index idx_inboxusgp_player on intake.box_usg_plr(player_id);
+
+-- drafthistory DraftHistory, one row per pick, upserted on refresh
+create table intake.draft (
+    person_id bigint not null,
+    player_name varchar(255),
+    season varchar(4) not null,
+    round_number int,
+    round_pick int,
+    overall_pick int,
+    draft_type varchar(50),
+    team_id bigint,
+    team_city varchar(255),
+    team_name varchar(255),
+    team_abbreviation varchar(10),
+    organization varchar(255),
+    organization_type varchar(255),
+    player_profile_flag int,
+    primary key (person_id, season)
+);
+
+create index idx_indraft_team on intake.draft(team_id);
+create index idx_indraft_szn on intake.draft(season);
+
+-- leaguestandingsv3 Standings, one snapshot per league & season per day
+-- snap_date is first, the etl adds it ahead of the api columns
+create table intake.standings (
+    snap_date date not null,
+    leagueid varchar(2) not null,
+    seasonid varchar(10) not null,
+    teamid bigint not null,
+    teamcity varchar(255),
+    teamname varchar(255),
+    teamslug varchar(255),
+    conference varchar(255),
+    conferencerecord varchar(20),
+    playoffrank int,
+    clinchindicator varchar(20),
+    division varchar(255),
+    divisionrecord varchar(20),
+    divisionrank int,
+    wins int,
+    losses int,
+    winpct numeric(7, 3),
+    leaguerank int,
+    record varchar(20),
+    home varchar(20),
+    road varchar(20),
+    l10 varchar(20),
+    last10home varchar(20),
+    last10road varchar(20),
+    ot varchar(20),
+    threeptsorless varchar(20),
+    tenptsormore varchar(20),
+    longhomestreak int,
+    strlonghomestreak varchar(20),
+    longroadstreak int,
+    strlongroadstreak varchar(20),
+    longwinstreak int,
+    longlossstreak int,
+    currenthomestreak int,
+    strcurrenthomestreak varchar(20),
+    currentroadstreak int,
+    strcurrentroadstreak varchar(20),
+    currentstreak int,
+    strcurrentstreak varchar(20),
+    conferencegamesback numeric(7, 3),
+    divisiongamesback numeric(7, 3),
+    clinchedconferencetitle int,
+    clincheddivisiontitle int,
+    clinchedplayoffbirth int,
+    clinchedplayin int,
+    eliminatedconference int,
+    eliminateddivision int,
+    aheadathalf varchar(20),
+    behindathalf varchar(20),
+    tiedathalf varchar(20),
+    aheadatthird varchar(20),
+    behindatthird varchar(20),
+    tiedatthird varchar(20),
+    score100pts varchar(20),
+    oppscore100pts varchar(20),
+    oppover500 varchar(20),
+    leadinfgpct varchar(20),
+    leadinreb varchar(20),
+    fewerturnovers varchar(20),
+    pointspg numeric(7, 3),
+    opppointspg numeric(7, 3),
+    diffpointspg numeric(7, 3),
+    vseast varchar(20),
+    vsatlantic varchar(20),
+    vscentral varchar(20),
+    vssoutheast varchar(20),
+    vswest varchar(20),
+    vsnorthwest varchar(20),
+    vspacific varchar(20),
+    vssouthwest varchar(20),
+    jan varchar(20),
+    feb varchar(20),
+    mar varchar(20),
+    apr varchar(20),
+    may varchar(20),
+    jun varchar(20),
+    jul varchar(20),
+    aug varchar(20),
+    sep varchar(20),
+    oct varchar(20),
+    nov varchar(20),
+    dec varchar(20),
+    preas varchar(20),
+    postas varchar(20),
+    primary key (snap_date, leagueid, seasonid, teamid)
+);
+
+create index idx_instand_team on intake.standings(teamid);
@@ -11,6 +11,7 @@ insert into lg.league values
 	(99, 'nat', 'NAT', 'Not Assigned to a Team'),
     (0, 'nba', 'NBA', 'National Basketball Association'),
     (1, 'wnba', 'WNBA', 'Women''s National Basketball Association'),
+    (2, 'gleague', 'GL', 'NBA G League'),
     (9, 'misc', 'MISC', 'Temporary/Miscellaneous League');
 
 create table lg.szn_type (
@@ -69,4 +69,20 @@ create table stats.tbox (
 create index idx_tbox_szn on stats.tbox(szn_id);
 create index idx_tbox_gdate on stats.tbox(gdate);
 create index idx_tbox_matchup on stats.tbox(matchup);
-create index idx_tbox_wl on stats.tbox(wl);
\ No newline at end of file
+create index idx_tbox_wl on stats.tbox(wl);
+
+-- shots from intake.shot rolled up by player, season & zone
+create table stats.shot_zone (
+    player_id bigint not null references lg.plr(player_id),
+    szn_id int not null references lg.szn(szn_id),
+    zone_basic varchar(50) not null,
+    zone_area varchar(50) not null,
+    zone_range varchar(50) not null,
+    fga int,
+    fgm int,
+    fgp numeric(5, 4),
+    avg_dist numeric(4, 1),
+    primary key (player_id, szn_id, zone_basic, zone_area, zone_range)
+);
+
+create index idx_shotzn_szn on stats.shot_zone(szn_id);
@@ -0,0 +1,30 @@
+/*
+fills lg.plr_crnt from the roster snapshots in intake.roster
+each team's latest snapshot is used, a player on more than one of those (traded
+between snapshots) is kept on the team from the most recent one
+must run after lg.sp_team_all_load & lg.sp_plr_all_load
+*/
+create or replace procedure lg.sp_plr_crnt()
+language plpgsql
+as $$
+begin
+    truncate lg.plr_crnt;
+
+    insert into lg.plr_crnt (lg_id, team_id, player_id, plr_cde)
+        select distinct on (a.player_id)
+            c.lg_id,
+            a.teamid,
+            a.player_id,
+            d.plr_cde
+        from intake.roster a
+        inner join (
+            select teamid, max(snap_date) as snap_date
+            from intake.roster
+            group by teamid
+        ) b on b.teamid = a.teamid and b.snap_date = a.snap_date
+        inner join lg.team c on c.team_id = a.teamid
+        inner join lg.plr d on d.player_id = a.player_id
+        order by a.player_id, a.snap_date desc
+    on conflict (player_id, team_id) do nothing;
+end; $$;
+-- call lg.sp_plr_crnt();
@@ -0,0 +1,22 @@
+-- draft picks with the player & team they link to
+-- left joins: drafted players who never played have no lg.plr row
+create or replace view lg.v_draft as
+select
+    a.person_id as "player_id",
+    coalesce(b.player, a.player_name) as "player",
+    b.lg_id,
+    cast(a.season as int) as "draft_year",
+    a.round_number as "round",
+    a.round_pick as "pick",
+    a.overall_pick,
+    a.draft_type,
+    a.team_id,
+    coalesce(c.team, a.team_abbreviation) as "team",
+    coalesce(c.team_long, a.team_name) as "team_long",
+    a.organization,
+    a.organization_type,
+    b.player_id is not null as "in_plr"
+from intake.draft a
+left join lg.plr b on b.player_id = a.person_id
+left join lg.team c on c.team_id = a.team_id
+order by a.season desc, a.overall_pick;
@@ -32,7 +32,9 @@ begin
             ftm,
             fta,
             ft_pct
-        from intake.gm_team -- join here to lg.team /lg.player
+        from intake.gm_team a
+        -- all-star etc teams aren't in lg.team, skip instead of failing the fk
+        where exists (select 1 from lg.team b where b.team_id = a.team_id)
     on conflict (game_id, team_id) do nothing;
 end; $$;
 -- call stats.sp_tbox();
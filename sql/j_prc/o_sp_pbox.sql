@@ -73,5 +73,6 @@ begin
             a.ft_pct
         from intake.gm_player a 
         inner join lg.plr b on b.player_id = a.player_id
+        inner join lg.team c on c.team_id = a.team_id -- no all-star teams
     on conflict (team_id, game_id, player_id) do nothing;
 end; $$;
\ No newline at end of file
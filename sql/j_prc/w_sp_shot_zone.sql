@@ -0,0 +1,37 @@
+/*
+rolls intake.shot up by player, season & zone into stats.shot_zone
+season comes from the player's game log row for the game, so playoff shots
+land in the playoff szn_id
+existing rows are updated, a player's zones change every night of the season
+*/
+create or replace procedure stats.sp_shot_zone()
+language plpgsql
+as $$
+begin
+    insert into stats.shot_zone
+        select
+            a.player_id,
+            b.season_id,
+            a.shot_zone_basic,
+            a.shot_zone_area,
+            a.shot_zone_range,
+            sum(a.shot_attempted_flag),
+            sum(a.shot_made_flag),
+            round(sum(a.shot_made_flag)::numeric
+                / nullif(sum(a.shot_attempted_flag), 0), 4),
+            round(avg(a.shot_distance), 1)
+        from intake.shot a
+        inner join intake.gm_player b
+            on b.game_id = a.game_id and b.player_id = a.player_id
+        inner join lg.plr c on c.player_id = a.player_id
+        group by
+            a.player_id, b.season_id,
+            a.shot_zone_basic, a.shot_zone_area, a.shot_zone_range
+    on conflict (player_id, szn_id, zone_basic, zone_area, zone_range)
+    do update set
+        fga = excluded.fga,
+        fgm = excluded.fgm,
+        fgp = excluded.fgp,
+        avg_dist = excluded.avg_dist;
+end; $$;
+-- call stats.sp_shot_zone();
@@ -27,6 +27,11 @@ begin
 	call lg.sp_plr_all_load();
 	raise notice e'player insert complete: %\n', fn_cntstr('lg.plr');
 
+	-- current team for each player from the latest roster snapshots
+	raise notice e'inserting current players into lg.plr_crnt...\n';
+	call lg.sp_plr_crnt();
+	raise notice e'current player insert complete: %\n', fn_cntstr('lg.plr_crnt');
+
 	/* INSERT A ROW INTO lg.plr FOR WNBA PLAYER ANGEL ROBINSON WITH PLAYER ID 
 	202270 this player had the ID 202270 in 2014 and 202657 in all years after
 	this was causing an error with loading stats.pbox table
@@ -48,6 +53,11 @@ begin
 	call stats.sp_pbox();
 	raise notice e'pbox insert complete: %\n', fn_cntstr('stats.pbox');
 
+	-- load shot_zone table with shots by player/season/zone after pbox
+	raise notice e'inserting shot zone aggregations into stats.shot_zone...\n';
+	call stats.sp_shot_zone();
+	raise notice e'shot zone insert complete: %\n', fn_cntstr('stats.shot_zone');
+
 	-- load api.plr_agg table with pbox stats 
 	raise notice e'inserting season/career stat aggregations into api.plr_agg...\n';
 	call api.sp_plr_agg();
@@ -25,11 +25,21 @@ begin
 	call lg.sp_plr_all_load();
 	raise notice e'player insert complete: %\n', fn_cntstr('lg.plr');
 
+	-- current team for each player from the latest roster snapshots
+	raise notice e'inserting current players into lg.plr_crnt...\n';
+	call lg.sp_plr_crnt();
+	raise notice e'current player insert complete: %\n', fn_cntstr('lg.plr_crnt');
+
 	-- load pbox table with player box scores after inserting player causing issue
 	raise notice e'inserting player box stats into stats.pbox...\n';
 	call stats.sp_pbox();
 	raise notice e'pbox insert complete: %\n', fn_cntstr('stats.pbox');
 
+	-- load shot_zone table with shots by player/season/zone after pbox
+	raise notice e'inserting shot zone aggregations into stats.shot_zone...\n';
+	call stats.sp_shot_zone();
+	raise notice e'shot zone insert complete: %\n', fn_cntstr('stats.shot_zone');
+
 	-- load api.plr_agg table with pbox stats 
 	raise notice e'inserting season/career stat aggregations into api.plr_agg...\n';
 	call api.sp_plr_agg();